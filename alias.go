@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// aliases returns the aliases of Aliases and AliasFile. The ones from the file
+// replace those of the same name in Aliases. A missing file is not an error.
+func (a *App) aliases() (map[string]string, error) {
+	aliases := make(map[string]string, len(a.Aliases))
+	for name, expansion := range a.Aliases {
+		aliases[name] = expansion
+	}
+	if a.AliasFile == "" {
+		return aliases, nil
+	}
+
+	f, err := os.Open(a.AliasFile)
+	if os.IsNotExist(err) {
+		return aliases, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[':
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: expected ']' at the end of the section", a.AliasFile, lineNo)
+			}
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+		case section == "alias":
+			i := strings.Index(line, "=")
+			if i <= 0 {
+				return nil, fmt.Errorf("%s:%d: expected <name> = <expansion>", a.AliasFile, lineNo)
+			}
+			aliases[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+		}
+	}
+	return aliases, scanner.Err()
+}
+
+// expandAliases replaces the first argument with its expansion for as long as
+// it is an alias and not a command, and appends the remaining arguments. If it
+// reaches a shell alias, the command of the alias is returned instead, with
+// the arguments to pass it.
+func (a *App) expandAliases(args []string) (expanded []string, shell string, err error) {
+	aliases, err := a.aliases()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var seen []string
+	for len(args) > 0 && a.Command(args[0]) == nil {
+		name := args[0]
+		expansion, ok := aliases[name]
+		if !ok {
+			break
+		}
+		for _, s := range seen {
+			if s == name {
+				return nil, "", fmt.Errorf("alias loop: %s -> %s", strings.Join(seen, " -> "), name)
+			}
+		}
+		seen = append(seen, name)
+
+		if strings.HasPrefix(expansion, "!") {
+			return args[1:], strings.TrimSpace(expansion[1:]), nil
+		}
+		words, err := tokenize(expansion)
+		if err != nil {
+			return nil, "", fmt.Errorf("alias '%s': %v", name, err)
+		}
+		if len(words) == 0 {
+			return nil, "", fmt.Errorf("alias '%s' is empty", name)
+		}
+		args = append(words, args[1:]...)
+	}
+	return args, "", nil
+}
+
+// runShellAlias runs the command of the shell alias name with the system
+// shell, passing it args as positional parameters.
+func (a *App) runShellAlias(name, command string, args []string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", append([]string{"/C", command}, args...)...)
+	} else {
+		cmd = exec.Command("sh", append([]string{"-c", command + ` "$@"`, command}, args...)...)
+	}
+	return a.runExternal(&PluginExitError{Plugin: name, Alias: true}, cmd)
+}
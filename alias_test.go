@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+var aliasTests = []struct {
+	aliases  map[string]string
+	args     []string
+	expected string
+	err      string
+}{
+	{map[string]string{"st": "status --short", "s": "st"}, []string{"s", "web"}, "status web|short", ""},
+	{map[string]string{"dw": `deploy "web server"`}, []string{"dw", "v2"}, "deploy web server,v2", ""},
+	{map[string]string{"loop": "again", "again": "loop --fast"}, []string{"loop"}, "", "alias loop: loop -> again -> loop"},
+	{map[string]string{"deploy": "status"}, []string{"deploy", "api"}, "deploy api", ""},
+}
+
+func TestApp_RunAlias(t *testing.T) {
+	for _, test := range aliasTests {
+		var calls []string
+		app := NewApp()
+		app.Name = "ops"
+		app.Aliases = test.aliases
+		app.Commands = []Command{
+			{
+				Name:  "status",
+				Flags: []Flag{BoolFlag{Name: "short"}},
+				Action: func(c *Context) {
+					calls = append(calls, "status "+strings.Join(c.Args(), " "))
+					if c.Bool("short") {
+						calls = append(calls, "short")
+					}
+				},
+			},
+			{
+				Name: "deploy",
+				Action: func(c *Context) {
+					calls = append(calls, "deploy "+strings.Join(c.Args(), ","))
+				},
+			},
+		}
+
+		err := app.Run(append([]string{"ops"}, test.args...))
+		if test.err == "" {
+			expect(t, err, nil)
+		} else if err == nil || err.Error() != test.err {
+			t.Errorf("%v: expected error %q, got %v", test.args, test.err, err)
+		}
+		expect(t, strings.Join(calls, "|"), test.expected)
+	}
+}
+
+func TestApp_AliasFile(t *testing.T) {
+	path := writeTempFile(t, `# user aliases
+[core]
+st = ignored
+
+[alias]
+  st = status
+  dp = deploy 'a b'
+`)
+	defer os.Remove(path)
+
+	var calls []string
+	app := NewApp()
+	app.Name = "ops"
+	app.Aliases = map[string]string{"s": "st"}
+	app.AliasFile = path
+	app.Commands = []Command{
+		{
+			Name: "deploy",
+			Action: func(c *Context) {
+				calls = append(calls, "deploy "+strings.Join(c.Args(), " "))
+			},
+		},
+	}
+
+	aliases, err := app.aliases()
+	expect(t, err, nil)
+	expect(t, aliases["st"], "status")
+	expect(t, aliases["dp"], "deploy 'a b'")
+	expect(t, aliases["s"], "st")
+
+	expect(t, app.Run([]string{"ops", "dp"}), nil)
+	expect(t, strings.Join(calls, "|"), "deploy a b")
+}
+
+func TestApp_AliasFileErrors(t *testing.T) {
+	path := writeTempFile(t, "[alias]\nst status\n")
+	defer os.Remove(path)
+
+	app := NewApp()
+	app.AliasFile = path
+	_, err := app.aliases()
+	expect(t, err.Error(), path+":2: expected <name> = <expansion>")
+
+	app.AliasFile = path + ".missing"
+	_, err = app.aliases()
+	expect(t, err, nil)
+}
+
+func TestApp_ExpandShellAlias(t *testing.T) {
+	app := NewApp()
+	app.Aliases = map[string]string{
+		"l":    "logs",
+		"logs": "!tail -f /var/log/ops.log",
+	}
+
+	args, shell, err := app.expandAliases([]string{"l", "-n", "5"})
+	expect(t, err, nil)
+	expect(t, shell, "tail -f /var/log/ops.log")
+	if !reflect.DeepEqual(args, []string{"-n", "5"}) {
+		t.Errorf("expected the arguments after the alias, got %q", args)
+	}
+}
+
+func TestAppHelpListsAliases(t *testing.T) {
+	oldPrinter := HelpPrinter
+	defer func() {
+		HelpPrinter = oldPrinter
+	}()
+	var out bytes.Buffer
+	HelpPrinter = func(templ string, data interface{}) {
+		template.Must(template.New("help").Parse(templ)).Execute(&out, data)
+	}
+
+	app := NewApp()
+	app.Name = "ops"
+	app.Aliases = map[string]string{
+		"st":   "status --short",
+		"s":    "st",
+		"dw":   `deploy "web server"`,
+		"loop": "again",
+	}
+	app.Run([]string{"ops", "help"})
+	if !strings.Contains(out.String(), "ALIASES:\n   dw\tdeploy \"web server\"\n   loop\tagain\n   s\tst\n   st\tstatus --short\n") {
+		t.Errorf("expected the aliases in the help output, got %q", out.String())
+	}
+}
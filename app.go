@@ -1,9 +1,14 @@
 package cli
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,19 +29,127 @@ type App struct {
 	Commands []Command
 	// List of flags to parse
 	Flags []Flag
+	// Prefix for environment variables derived for every flag from its command
+	// path and name, e.g. MYAPP gives MYAPP_CLUSTER_NODE_TIMEOUT
+	EnvPrefix string
 	// An action to execute before any commands are run, but after the context is ready
 	// If a non-nil error is returned, no commands are run
 	Before func(context *Context) error
 	// The action to execute when no command is specified
 	Action func(context *Context)
+	// An action to execute after the command or Action has run, if Before succeeded
+	After func(context *Context) error
 	// Execute this function if the proper command cannot be found
 	CommandNotFound func(context *Context, command string)
+	// Print completion candidates when the last argument is --generate-bash-completion
+	EnableBashCompletion bool
 	// Compilation date
 	Compiled time.Time
 	// Author
 	Author string
 	// Author e-mail
 	Email string
+	// Reader for input, such as answers to prompts. Defaults to os.Stdin
+	Reader io.Reader
+	// Writer for output and help. Defaults to os.Stdout
+	Writer io.Writer
+	// Writer for warnings and errors. Defaults to os.Stderr
+	ErrWriter io.Writer
+	// Prints help to w. Defaults to HelpPrinter if it was replaced, or else to
+	// executing templ with data
+	HelpPrinter func(w io.Writer, templ string, data interface{})
+	// Prints the version. Defaults to VersionPrinter
+	VersionPrinter func(c *Context)
+	// Templates for the help of the App, a command and a subcommand. Default to
+	// AppHelpTemplate, CommandHelpTemplate and SubcommandHelpTemplate
+	AppHelpTemplate        string
+	CommandHelpTemplate    string
+	SubcommandHelpTemplate string
+	// Colorize help written to a terminal, unless NO_COLOR or NoColorFlag is set
+	ColorHelp bool
+	// Show help that is longer than the terminal through $PAGER, or else less,
+	// unless NoPagerFlag is given
+	PageHelp bool
+	// Fail with an error instead of warning when a deprecated flag or command is used
+	FailOnDeprecated bool
+	// Cancel the context of RunContext on the first SIGINT or SIGTERM and exit on the second
+	HandleSignals bool
+	// How long to wait for the actions to return after the first signal before
+	// exiting anyway. Zero waits until a second signal is received
+	ShutdownTimeout time.Duration
+	// Return a *PanicError instead of crashing when an action panics
+	RecoverPanics bool
+	// Where to save a crash report when a panic is recovered. Empty saves none
+	CrashReportDir string
+	// Add the global flags --output/-o, --columns and --sort, which choose the
+	// format of Context.Render
+	EnableOutput bool
+	// Add the global flags --verbose, --quiet, --log-level, --log-format and
+	// --log-file, which configure Context.Logger
+	EnableLogging bool
+	// Add the hidden global flags --cpuprofile, --memprofile, --trace and --timing,
+	// which profile the run and time its phases
+	EnableProfiling bool
+	// Add a 'version' command that shows how the program was built, also as JSON
+	EnableVersionCommand bool
+	// Add a 'shell' command that runs commands typed interactively
+	EnableShell bool
+	// The prompt of the shell. Defaults to the name of the program followed by "> "
+	ShellPrompt string
+	// File that keeps the shell history between sessions, without the lines that
+	// give a secret flag a value. Empty keeps none
+	ShellHistoryFile string
+	// Add a 'run-script' command that runs the commands in a file
+	EnableScripts bool
+	// Run an executable named <name>-<command>, found in PluginDirs or on PATH,
+	// for a command that does not exist
+	EnablePlugins bool
+	// Directories searched for plugins before PATH
+	PluginDirs []string
+	// Names of the global flags whose values are exported to plugins as
+	// environment variables. Secret flags are never exported
+	PluginFlags []string
+	// List the plugins in the help of the App. Finding them reads every
+	// directory in PluginDirs and on PATH
+	ListPlugins bool
+	// Aliases for commands, e.g. "st" for "status --short". An expansion starting
+	// with ! is run by the shell instead
+	Aliases map[string]string
+	// File with an [alias] section of <name> = <expansion> lines that add to Aliases
+	AliasFile string
+	// Replace every argument @path with the arguments read from the file path
+	EnableResponseFiles bool
+	// Read one argument per line from response files instead of splitting them
+	// with shell quoting rules
+	ResponseFileLines bool
+	// Translations of messages and help text. Descriptions of commands and flags
+	// are translated too, e.g. with the IDs command.remote.short and flag.verbose
+	Catalog Catalog
+	// The locale of the translations used, e.g. "de_AT". Defaults to the locale
+	// set by LC_ALL, LC_MESSAGES or LANG
+	Locale string
+
+	// deprecation warnings already shown, created on the first one and shared
+	// by later copies of the App
+	warned *warnings
+}
+
+// warnings records the deprecation warnings shown by an App.
+type warnings struct {
+	mu    sync.Mutex
+	shown map[string]bool
+}
+
+// warnedMu guards the creation of App.warned.
+var warnedMu sync.Mutex
+
+// copy returns a shallow copy of the App. Use it rather than *a while the App
+// may run, since deprecated sets App.warned the first time it warns.
+func (a *App) copy() *App {
+	warnedMu.Lock()
+	defer warnedMu.Unlock()
+	c := *a
+	return &c
 }
 
 // NewApp creates a new cli Application with some reasonable defaults.
@@ -46,39 +159,71 @@ func NewApp() *App {
 		Exec:        os.Args[0],
 		Description: "A new application",
 		Usage:       os.Args[0] + " [options] <command>",
-		Version:     "0.0.0",
+		Version:     mainVersion(),
 		Action:      helpCommand.Action,
 		Compiled:    compileTime(),
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrWriter:   os.Stderr,
 	}
 }
 
 // Run is the entry point to the cli app. It parses the arguments slice and routes to the
 // proper flag/args combination.
 func (a *App) Run(arguments []string) error {
-	// append help to commands
-	if a.Command(helpCommand.Name) == nil {
-		a.Commands = append(a.Commands, helpCommand)
+	return a.RunContext(context.Background(), arguments)
+}
+
+// RunContext is like Run, but the actions can read ctx with Context.Context to learn
+// when they should stop. If HandleSignals is set, ctx is also cancelled on SIGINT or SIGTERM.
+func (a *App) RunContext(ctx context.Context, arguments []string) (err error) {
+	defer a.recoverPanic(arguments, &err)
+	start := time.Now()
+
+	if a.HandleSignals {
+		var stop func()
+		ctx, stop = a.handleSignals(ctx)
+		defer stop()
 	}
 
-	// append version flag
-	a.appendFlag(VersionFlag)
+	if a.isCompletionRequest(arguments) {
+		a.printCompletions(arguments[1 : len(arguments)-1])
+		return nil
+	}
+
+	if a.EnableResponseFiles && len(arguments) > 1 {
+		expanded, err := a.expandResponseFiles(arguments[1:])
+		if err != nil {
+			return err
+		}
+		arguments = append([]string{arguments[0]}, expanded...)
+	}
 
 	// parse flags
-	set := flagSet(a.Name, a.Flags)
+	flags := a.flags()
+	prefixed := a.envPrefixed(flags)
+	set, err := flagSet(a.Name, prefixed, a.reader())
+	if err != nil {
+		return err
+	}
 	set.SetOutput(ioutil.Discard)
-	err := set.Parse(arguments[1:])
-	nerr := normalizeFlags(a.Flags, set)
+	err = set.Parse(arguments[1:])
+	if derr := a.checkDeprecatedFlags(flags, set); derr != nil {
+		return derr
+	}
+	nerr := a.normalizeFlags(flags, set)
 	if nerr != nil {
-		fmt.Println(nerr)
+		fmt.Fprintln(a.writer(), nerr)
 		context := NewContext(a, set, set)
 		ShowAppHelp(context)
-		fmt.Println("")
+		fmt.Fprintln(a.writer(), "")
 		return nerr
 	}
 	context := NewContext(a, set, set)
+	context.ctx = ctx
 
 	if err != nil {
-		fmt.Printf("Incorrect Usage - type '%s help' for info\n\n", a.Exec)
+		fmt.Fprintf(a.writer(), a.message("incorrect-usage")+"\n\n", a.Exec)
 		return err
 	}
 
@@ -86,20 +231,47 @@ func (a *App) Run(arguments []string) error {
 		return nil
 	}
 
+	if err := context.promptFlags(prefixed, a.isInteractive()); err != nil {
+		return err
+	}
+
+	if a.EnableLogging {
+		logger, closeLog, err := a.newLogger(context)
+		if err != nil {
+			return err
+		}
+		defer closeLog()
+		context.logger = logger
+	}
+
+	prof, err := a.startProfiling(context, start)
+	if err != nil {
+		return err
+	}
+	defer prof.stop()
+	prof.mark("flags")
+
 	if a.Before != nil {
 		err := a.Before(context)
+		prof.mark("before")
 		if err != nil {
 			return err
 		}
 	}
 
-	args := context.Args()
-	if args.Present() {
-		name := args.First()
-		c := a.Command(name)
-		if c != nil {
-			return c.Run(context)
-		}
+	prof.startAction()
+	if a.After != nil {
+		defer func() {
+			prof.mark("action")
+			if aerr := a.After(context); aerr != nil && err == nil {
+				err = aerr
+			}
+			prof.mark("after")
+		}()
+	}
+
+	if ok, err := a.dispatch(context); ok {
+		return err
 	}
 
 	// Run default Action
@@ -107,10 +279,44 @@ func (a *App) Run(arguments []string) error {
 	return nil
 }
 
+// dispatch runs the alias, command or plugin named by the first argument of c,
+// the arguments after the global flags. ok is false if there is none, in which
+// case nothing is run.
+func (a *App) dispatch(c *Context) (ok bool, err error) {
+	args := c.Args()
+	if !args.Present() {
+		return false, nil
+	}
+	expanded, shell, err := a.expandAliases(args)
+	if err != nil {
+		return true, err
+	}
+	if shell != "" {
+		return true, a.runShellAlias(args.First(), shell, expanded)
+	}
+	if len(expanded) != len(args) || expanded[0] != args[0] {
+		c.flagSet.Parse(append([]string{"--"}, expanded...))
+		args = c.Args()
+	}
+
+	name := args.First()
+	if command := a.Command(name); command != nil {
+		return true, command.Run(c)
+	}
+	if p := a.plugin(name); p != nil {
+		return true, a.runPlugin(c, p, args[1:])
+	}
+	return false, nil
+}
+
 // RunAndExitOnError is another entry point to the cli app. It takes care of passing
 // arguments and error handling.
 func (a *App) RunAndExitOnError() {
 	if err := a.Run(os.Args); err != nil {
+		// a plugin or shell alias has already reported its own error
+		if e, ok := err.(*PluginExitError); ok {
+			os.Exit(e.Code)
+		}
 		os.Stderr.WriteString(fmt.Sprintln(err))
 		os.Exit(1)
 	}
@@ -118,7 +324,7 @@ func (a *App) RunAndExitOnError() {
 
 // Command returns the named command on App. Returns nil if the command does not exist.
 func (a *App) Command(name string) *Command {
-	for _, c := range a.Commands {
+	for _, c := range a.commands() {
 		if c.HasName(name) {
 			return &c
 		}
@@ -127,25 +333,205 @@ func (a *App) Command(name string) *Command {
 	return nil
 }
 
-func (a *App) hasFlag(flag Flag) bool {
-	for _, f := range a.Flags {
-		if flag == f {
-			return true
+// commands returns the commands of the App followed by the built-in ones it
+// does not replace.
+func (a *App) commands() []Command {
+	commands := append([]Command{}, a.Commands...)
+	builtins := []Command{helpCommand}
+	if a.EnableShell {
+		builtins = append(builtins, shellCommand)
+	}
+	if a.EnableScripts {
+		builtins = append(builtins, scriptCommand)
+	}
+	if a.EnableVersionCommand {
+		builtins = append(builtins, versionCommand)
+	}
+	for _, b := range builtins {
+		if !hasCommand(commands, b.Name) {
+			commands = append(commands, b)
 		}
 	}
+	return commands
+}
 
+func hasCommand(commands []Command, name string) bool {
+	for _, c := range commands {
+		if c.HasName(name) {
+			return true
+		}
+	}
 	return false
 }
 
-func (a *App) appendFlag(flag Flag) {
-	if !a.hasFlag(flag) {
-		a.Flags = append(a.Flags, flag)
+// flags returns the flags of the App followed by the built-in flags whose
+// names none of them has: the version flag, the output flags with EnableOutput,
+// NoInputFlag if a flag has a prompt, NoColorFlag with ColorHelp, NoPagerFlag
+// with PageHelp, and the logging and profiling flags with EnableLogging and
+// EnableProfiling.
+func (a *App) flags() []Flag {
+	flags := append([]Flag{}, a.Flags...)
+	builtins := []Flag{VersionFlag}
+	if a.EnableOutput {
+		builtins = append(builtins, OutputFlag, ColumnsFlag, SortFlag)
+	}
+	if a.hasPrompts() {
+		builtins = append(builtins, NoInputFlag)
+	}
+	if a.ColorHelp {
+		builtins = append(builtins, NoColorFlag)
+	}
+	if a.PageHelp {
+		builtins = append(builtins, NoPagerFlag)
+	}
+	if a.EnableLogging {
+		builtins = append(builtins, loggingFlags()...)
+	}
+	if a.EnableProfiling {
+		builtins = append(builtins, profilingFlags()...)
+	}
+	for _, f := range builtins {
+		if !a.hasFlag(f) {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+func (a *App) reader() io.Reader {
+	if a.Reader == nil {
+		return os.Stdin
+	}
+	return a.Reader
+}
+
+func (a *App) writer() io.Writer {
+	if a.Writer == nil {
+		return os.Stdout
+	}
+	return a.Writer
+}
+
+func (a *App) errWriter() io.Writer {
+	if a.ErrWriter == nil {
+		return os.Stderr
+	}
+	return a.ErrWriter
+}
+
+// deprecated reports the use of a deprecated flag or command, described by
+// what, with a warning the first time it is used or, if FailOnDeprecated is
+// set, with an error.
+func (a *App) deprecated(what, message string) error {
+	if a.FailOnDeprecated {
+		return fmt.Errorf("%s is deprecated: %s", what, message)
+	}
+	warnedMu.Lock()
+	if a.warned == nil {
+		a.warned = &warnings{shown: make(map[string]bool)}
+	}
+	w := a.warned
+	warnedMu.Unlock()
+
+	w.mu.Lock()
+	shown := w.shown[what]
+	w.shown[what] = true
+	w.mu.Unlock()
+	if shown {
+		return nil
+	}
+	fmt.Fprintf(a.errWriter(), a.message("deprecated-warning")+"\n", what, message)
+	return nil
+}
+
+// checkDeprecatedFlags reports the deprecated flags given on the command line.
+// It must be called before normalizeFlags marks the other forms of a flag as set.
+func (a *App) checkDeprecatedFlags(flags []Flag, set *flag.FlagSet) error {
+	visited := make(map[string]bool)
+	set.Visit(func(f *flag.Flag) {
+		visited[f.Name] = true
+	})
+	for _, f := range flags {
+		df, ok := f.(deprecatedFlag)
+		if !ok || df.deprecated() == "" {
+			continue
+		}
+		used := ""
+		eachName(f.getName(), func(name string) {
+			if used == "" && visited[name] {
+				used = name
+			} else if used == "" && visited["no-"+name] {
+				used = "no-" + name
+			}
+		})
+		if used != "" {
+			if err := a.deprecated("flag -"+used, df.deprecated()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasFlag reports whether a flag of the App has one of the names of flag, so
+// that flag cannot be added to the global flags.
+func (a *App) hasFlag(flag Flag) bool {
+	names := make(map[string]bool)
+	for _, f := range a.Flags {
+		eachName(f.getName(), func(name string) {
+			names[name] = true
+			if bf, ok := f.(BoolFlag); ok && bf.Negatable && len(name) > 1 {
+				names["no-"+name] = true
+			}
+		})
+	}
+
+	found := false
+	eachName(flag.getName(), func(name string) {
+		found = found || names[name]
+	})
+	return found
+}
+
+// envPrefixed returns the flags with an environment variable derived from
+// EnvPrefix, the command path and the flag name added to each. The built-in
+// version flag is left alone.
+func (a *App) envPrefixed(flags []Flag, path ...string) []Flag {
+	if a.EnvPrefix == "" {
+		return flags
 	}
+
+	prefixed := make([]Flag, len(flags))
+	for i, f := range flags {
+		if ef, ok := f.(envFlag); ok && f.getName() != VersionFlag.Name {
+			f = ef.withEnvVars(envVarName(a.EnvPrefix, path, f.getName()))
+		}
+		prefixed[i] = f
+	}
+	return prefixed
+}
+
+// envVarName derives the environment variable for a flag, e.g. the flag
+// "node-timeout, t" of the command "cluster" becomes MYAPP_CLUSTER_NODE_TIMEOUT.
+func envVarName(prefix string, path []string, flagName string) string {
+	name := strings.Trim(strings.Split(flagName, ",")[0], " ")
+	parts := []string{strings.TrimSuffix(prefix, "_")}
+	for _, p := range path {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	parts = append(parts, name)
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(strings.Join(parts, "_")))
 }
 
-// Tries to find out when this binary was compiled.
+// Tries to find out when this binary was compiled, from the time of the
+// commit it was built from or else the time the binary was modified.
 // Returns the current time if it fails to find it.
 func compileTime() time.Time {
+	if t, ok := vcsTime(); ok {
+		return t
+	}
 	info, err := os.Stat(os.Args[0])
 	if err != nil {
 		return time.Now()
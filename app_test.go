@@ -1,9 +1,14 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func ExampleApp() {
@@ -138,6 +143,26 @@ func TestAppHelpPrinter(t *testing.T) {
 	}
 }
 
+func TestAppHelpPrinterWrapped(t *testing.T) {
+	oldPrinter := HelpPrinter
+	defer func() {
+		HelpPrinter = oldPrinter
+	}()
+
+	var out bytes.Buffer
+	HelpPrinter = func(template string, data interface{}) {
+		fmt.Fprint(&out, "wrapped ")
+		oldPrinter(template, data)
+	}
+
+	app := NewApp()
+	app.Name = "greet"
+	app.Writer = &out
+	app.AppHelpTemplate = "{{.Name}} help\n"
+	expect(t, app.Run([]string{"greet", "help"}), nil)
+	expect(t, out.String(), "wrapped greet help\n")
+}
+
 func TestAppVersionPrinter(t *testing.T) {
 	oldPrinter := VersionPrinter
 	defer func() {
@@ -180,3 +205,237 @@ func TestAppCommandNotFound(t *testing.T) {
 	expect(t, beforeRun, true)
 	expect(t, subcommandRun, false)
 }
+
+func TestApp_EnvPrefix(t *testing.T) {
+	os.Setenv("MYAPP_DEBUG", "true")
+	os.Setenv("MYAPP_CLUSTER_REGION", "eu-west-1")
+	os.Setenv("MYAPP_CLUSTER_NODE_NODE_TIMEOUT", "30s")
+	os.Setenv("MYAPP_VERSION", "true")
+	defer func() {
+		for _, name := range []string{"MYAPP_DEBUG", "MYAPP_CLUSTER_REGION", "MYAPP_CLUSTER_NODE_NODE_TIMEOUT", "MYAPP_VERSION"} {
+			os.Unsetenv(name)
+		}
+	}()
+
+	var debug bool
+	var region string
+	var timeout time.Duration
+
+	app := NewApp()
+	app.EnvPrefix = "MYAPP"
+	app.Flags = []Flag{
+		BoolFlag{Name: "debug"},
+	}
+	app.Commands = []Command{
+		{
+			Name:  "cluster",
+			Flags: []Flag{StringFlag{Name: "region, r"}},
+			Subcommands: []Subcommand{
+				{
+					Name:  "node",
+					Flags: []Flag{DurationFlag{Name: "node-timeout"}},
+					Action: func(c *Context) {
+						debug = c.GlobalBool("debug")
+						timeout = c.Duration("node-timeout")
+					},
+				},
+			},
+			Action: func(c *Context) {
+				region = c.String("r")
+			},
+		},
+	}
+
+	err := app.Run([]string{"myapp", "cluster", "node"})
+	expect(t, err, nil)
+	expect(t, debug, true)
+	expect(t, timeout, 30*time.Second)
+
+	err = app.Run([]string{"myapp", "cluster"})
+	expect(t, err, nil)
+	expect(t, region, "eu-west-1")
+}
+
+func TestApp_EnvPrefixHelp(t *testing.T) {
+	oldPrinter := HelpPrinter
+	defer func() {
+		HelpPrinter = oldPrinter
+	}()
+
+	var flags []Flag
+	HelpPrinter = func(template string, data interface{}) {
+		flags = data.(*App).Flags
+	}
+
+	app := NewApp()
+	app.EnvPrefix = "MYAPP_"
+	app.Flags = []Flag{
+		StringFlag{Name: "log-level", EnvVar: "LOG_LEVEL"},
+	}
+	app.Run([]string{"myapp", "help"})
+
+	expect(t, flags[0].String(), "-log-level \t [$LOG_LEVEL, $MYAPP_LOG_LEVEL]")
+	expect(t, flags[1].String(), "-version\tprint the version")
+}
+
+func TestApp_DeprecatedFlagWarnsOnce(t *testing.T) {
+	var out bytes.Buffer
+	var name string
+
+	app := NewApp()
+	app.ErrWriter = &out
+	app.Flags = []Flag{
+		StringFlag{Name: "user, u", Deprecated: "use --name instead"},
+		StringFlag{Name: "name"},
+	}
+	app.Action = func(c *Context) {
+		name = c.String("user")
+	}
+
+	err := app.Run([]string{"greet", "-u", "bob"})
+	expect(t, err, nil)
+	expect(t, name, "bob")
+	err = app.Run([]string{"greet", "-user", "bob"})
+	expect(t, err, nil)
+	err = app.Run([]string{"greet", "-u", "bob"})
+	expect(t, err, nil)
+	expect(t, out.String(), "Warning: flag -u is deprecated: use --name instead\nWarning: flag -user is deprecated: use --name instead\n")
+}
+
+func TestApp_DeprecatedCommand(t *testing.T) {
+	var out bytes.Buffer
+	commandRun := false
+
+	app := NewApp()
+	app.ErrWriter = &out
+	app.Commands = []Command{
+		{
+			Name:       "ls",
+			Deprecated: "use 'list' instead",
+			Action: func(c *Context) {
+				commandRun = true
+			},
+		},
+	}
+
+	err := app.Run([]string{"app", "ls"})
+	expect(t, err, nil)
+	expect(t, commandRun, true)
+	expect(t, out.String(), "Warning: command 'ls' is deprecated: use 'list' instead\n")
+}
+
+func TestApp_FailOnDeprecated(t *testing.T) {
+	actionRun := false
+
+	app := NewApp()
+	app.FailOnDeprecated = true
+	app.Commands = []Command{
+		{
+			Name:  "remote",
+			Flags: []Flag{BoolFlag{Name: "color", Negatable: true, Deprecated: "set $NO_COLOR instead"}},
+			Action: func(c *Context) {
+				actionRun = true
+			},
+		},
+	}
+
+	err := app.Run([]string{"app", "remote", "--no-color"})
+	expect(t, err.Error(), "flag -no-color is deprecated: set $NO_COLOR instead")
+	expect(t, actionRun, false)
+}
+
+func TestApp_DeprecatedHiddenFromHelp(t *testing.T) {
+	oldPrinter := HelpPrinter
+	defer func() {
+		HelpPrinter = oldPrinter
+	}()
+
+	var shown *App
+	HelpPrinter = func(template string, data interface{}) {
+		shown = data.(*App)
+	}
+
+	app := NewApp()
+	app.Flags = []Flag{
+		IntFlag{Name: "old", Deprecated: "use --new instead"},
+		IntFlag{Name: "new"},
+	}
+	app.Commands = []Command{
+		{Name: "ls", Deprecated: "use 'list' instead"},
+		{Name: "list"},
+	}
+	app.Run([]string{"app", "help"})
+
+	expect(t, len(shown.Flags), 2)
+	expect(t, shown.Flags[0].getName(), "new")
+	expect(t, len(shown.Commands), 2)
+	expect(t, shown.Commands[0].Name, "list")
+}
+
+func TestApp_RunContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	var seen []interface{}
+	record := func(c *Context) {
+		seen = append(seen, c.Context().Value(key{}))
+	}
+
+	app := NewApp()
+	app.Before = func(c *Context) error {
+		record(c)
+		return nil
+	}
+	app.After = func(c *Context) error {
+		record(c)
+		return nil
+	}
+	app.Commands = []Command{
+		{
+			Name: "remote",
+			Subcommands: []Subcommand{
+				{Name: "add", Action: record},
+			},
+		},
+	}
+
+	err := app.RunContext(ctx, []string{"app", "remote", "add"})
+	expect(t, err, nil)
+	expect(t, len(seen), 3)
+	for _, v := range seen {
+		expect(t, v, "value")
+	}
+}
+
+func TestApp_After(t *testing.T) {
+	var calls []string
+	app := NewApp()
+	app.After = func(c *Context) error {
+		calls = append(calls, "app after")
+		return errors.New("after failed")
+	}
+	app.Commands = []Command{
+		{
+			Name: "sync",
+			Before: func(c *Context) error {
+				calls = append(calls, "sync before")
+				return nil
+			},
+			Action: func(c *Context) {
+				calls = append(calls, "sync")
+			},
+			After: func(c *Context) error {
+				calls = append(calls, "sync after")
+				return nil
+			},
+		},
+	}
+
+	err := app.Run([]string{"app", "sync"})
+	expect(t, err.Error(), "after failed")
+	expect(t, strings.Join(calls, ", "), "sync before, sync, sync after, app after")
+}
+
+func TestContext_ContextDefault(t *testing.T) {
+	c := NewContext(nil, nil, nil)
+	expect(t, c.Context(), context.Background())
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"text/tabwriter"
+	"time"
+)
+
+// BuildInfo describes the program and how it was built, as recorded by the Go
+// toolchain. Builds from a VCS checkout record its revision and commit time.
+type BuildInfo struct {
+	Name       string     `json:"name"`
+	Version    string     `json:"version"`
+	Revision   string     `json:"revision,omitempty"`
+	Dirty      bool       `json:"dirty,omitempty"`
+	CommitTime *time.Time `json:"commitTime,omitempty"`
+	GoVersion  string     `json:"goVersion,omitempty"`
+	// The path of the main module
+	Module       string       `json:"module,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+// Dependency is a module the program was built with.
+type Dependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// The module that replaces it, as path@version, if it is replaced
+	Replace string `json:"replace,omitempty"`
+}
+
+// readBuildInfo is replaced by tests.
+var readBuildInfo = debug.ReadBuildInfo
+
+var versionCommand = Command{
+	Name:             "version",
+	ShortDescription: "Shows the version and how the program was built",
+	Usage:            "version [--json | --short]",
+	Flags: []Flag{
+		BoolFlag{Name: "json", Description: "print the build information as JSON"},
+		BoolFlag{Name: "short", Description: "print only the version"},
+	},
+	run: func(c *Context) error {
+		switch {
+		case c.Bool("json"):
+			data, err := json.MarshalIndent(c.App.BuildInfo(), "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.App.writer(), "%s\n", data)
+		case c.Bool("short"):
+			fmt.Fprintln(c.App.writer(), c.App.Version)
+		default:
+			ShowVersion(c)
+		}
+		return nil
+	},
+}
+
+// BuildInfo returns the name and version of the App with what the Go toolchain
+// recorded about the build of the program.
+func (a *App) BuildInfo() BuildInfo {
+	info := BuildInfo{Name: a.Name, Version: a.Version}
+	bi, ok := readBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = bi.GoVersion
+	info.Module = bi.Main.Path
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.modified":
+			info.Dirty = s.Value == "true"
+		case "vcs.time":
+			if t, err := time.Parse(time.RFC3339, s.Value); err == nil {
+				info.CommitTime = &t
+			}
+		}
+	}
+	for _, m := range bi.Deps {
+		d := Dependency{Path: m.Path, Version: m.Version}
+		if m.Replace != nil {
+			d.Replace = m.Replace.Path
+			if m.Replace.Version != "" {
+				d.Replace += "@" + m.Replace.Version
+			}
+		}
+		info.Dependencies = append(info.Dependencies, d)
+	}
+	return info
+}
+
+func printVersion(c *Context) {
+	info := c.App.BuildInfo()
+	tw := tabwriter.NewWriter(c.App.writer(), 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "%v version %v\n", info.Name, info.Version)
+	if info.Revision != "" {
+		modified := ""
+		if info.Dirty {
+			modified = " (modified)"
+		}
+		fmt.Fprintf(tw, "  revision:\t%s%s\n", info.Revision, modified)
+	}
+	if info.CommitTime != nil {
+		fmt.Fprintf(tw, "  committed:\t%s\n", info.CommitTime.Format(time.RFC3339))
+	}
+	if info.GoVersion != "" {
+		fmt.Fprintf(tw, "  go:\t%s\n", info.GoVersion)
+	}
+	tw.Flush()
+	if len(info.Dependencies) > 0 {
+		fmt.Fprintln(c.App.writer(), "  dependencies:")
+		tw = tabwriter.NewWriter(c.App.writer(), 0, 8, 1, ' ', 0)
+		for _, d := range info.Dependencies {
+			if d.Replace != "" {
+				fmt.Fprintf(tw, "    %s\t%s\t=> %s\n", d.Path, d.Version, d.Replace)
+			} else {
+				fmt.Fprintf(tw, "    %s\t%s\n", d.Path, d.Version)
+			}
+		}
+		tw.Flush()
+	}
+}
+
+// mainVersion returns the version of the main module, for programs installed
+// with go install, or "0.0.0" if it is not known.
+func mainVersion() string {
+	if bi, ok := readBuildInfo(); ok && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		return bi.Main.Version
+	}
+	return "0.0.0"
+}
+
+// vcsTime returns the time of the commit the program was built from.
+func vcsTime() (time.Time, bool) {
+	bi, ok := readBuildInfo()
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, s := range bi.Settings {
+		if s.Key == "vcs.time" {
+			t, err := time.Parse(time.RFC3339, s.Value)
+			return t, err == nil
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func withBuildInfo(bi *debug.BuildInfo) func() {
+	old := readBuildInfo
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return bi, bi != nil
+	}
+	return func() {
+		readBuildInfo = old
+	}
+}
+
+var testBuildInfo = &debug.BuildInfo{
+	GoVersion: "go1.22.1",
+	Main:      debug.Module{Path: "example.com/ops", Version: "v1.4.0"},
+	Deps: []*debug.Module{
+		{Path: "example.com/lib", Version: "v0.3.0"},
+		{Path: "example.com/fork", Version: "v1.0.0", Replace: &debug.Module{Path: "../fork"}},
+	},
+	Settings: []debug.BuildSetting{
+		{Key: "vcs", Value: "git"},
+		{Key: "vcs.revision", Value: "0123abcd"},
+		{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+		{Key: "vcs.modified", Value: "true"},
+	},
+}
+
+func TestNewApp_BuildInfoDefaults(t *testing.T) {
+	defer withBuildInfo(testBuildInfo)()
+	app := NewApp()
+	expect(t, app.Version, "v1.4.0")
+	expect(t, app.Compiled.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)), true)
+
+	defer withBuildInfo(&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}})()
+	expect(t, NewApp().Version, "0.0.0")
+}
+
+func TestApp_BuildInfo(t *testing.T) {
+	defer withBuildInfo(testBuildInfo)()
+	info := NewApp().BuildInfo()
+	expect(t, info.Version, "v1.4.0")
+	expect(t, info.Revision, "0123abcd")
+	expect(t, info.Dirty, true)
+	expect(t, info.CommitTime.Format(time.RFC3339), "2024-05-01T10:00:00Z")
+	expect(t, info.GoVersion, "go1.22.1")
+	expect(t, info.Module, "example.com/ops")
+	expect(t, len(info.Dependencies), 2)
+	expect(t, info.Dependencies[1], Dependency{Path: "example.com/fork", Version: "v1.0.0", Replace: "../fork"})
+}
+
+func TestVersionCommand(t *testing.T) {
+	defer withBuildInfo(testBuildInfo)()
+	var out bytes.Buffer
+	app := NewApp()
+	app.Name = "ops"
+	app.Writer = &out
+	app.EnableVersionCommand = true
+
+	expect(t, app.Run([]string{"ops", "version"}), nil)
+	expect(t, out.String(), `ops version v1.4.0
+  revision:  0123abcd (modified)
+  committed: 2024-05-01T10:00:00Z
+  go:        go1.22.1
+  dependencies:
+    example.com/lib  v0.3.0
+    example.com/fork v1.0.0 => ../fork
+`)
+
+	out.Reset()
+	expect(t, app.Run([]string{"ops", "version", "--short"}), nil)
+	expect(t, out.String(), "v1.4.0\n")
+
+	out.Reset()
+	expect(t, app.Run([]string{"ops", "version", "--json"}), nil)
+	var info BuildInfo
+	expect(t, json.Unmarshal(out.Bytes(), &info), nil)
+	expect(t, info.Name, "ops")
+	expect(t, info.Revision, "0123abcd")
+}
+
+func TestVersionFlag_NoBuildInfo(t *testing.T) {
+	defer withBuildInfo(nil)()
+	var out bytes.Buffer
+	app := NewApp()
+	app.Name = "ops"
+	app.Writer = &out
+	app.Version = "1.0.0"
+	expect(t, app.Run([]string{"ops", "--version"}), nil)
+	expect(t, out.String(), "ops version 1.0.0\n")
+}
@@ -4,7 +4,7 @@ import (
 	"os"
 )
 
-func Example() {
+func ExampleApp_Run() {
 	app := NewApp()
 	app.Name = "todo"
 	app.Usage = "task list on the command line"
@@ -0,0 +1,204 @@
+// Package clitest runs cli.App instances in tests and captures what they print.
+//
+//	res := clitest.Run(t, app, clitest.Invocation{Args: []string{"greet", "--name", "Jo"}})
+//	if res.ExitCode != 0 {
+//		t.Fatal(res.Stderr)
+//	}
+//	clitest.AssertGolden(t, "greet", res.Stdout)
+//
+// Run replaces process-wide state such as os.Stdout and the working directory
+// while the App runs, so calls to it are serialized.
+package clitest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/davelaursen/cli"
+)
+
+var update = flag.Bool("update-golden", false, "rewrite the golden files compared by clitest.AssertGolden")
+
+// mu serializes Run, which changes process-wide state.
+var mu sync.Mutex
+
+// Invocation describes how to run an App.
+type Invocation struct {
+	// The arguments after the program name
+	Args []string
+	// Environment variables to set while the App runs
+	Env map[string]string
+	// What the App reads from stdin
+	Stdin string
+	// The working directory of the App. Empty keeps the current one
+	Dir string
+}
+
+// Result is what an App printed and returned.
+type Result struct {
+	Stdout string
+	Stderr string
+	// The status RunAndExitOnError would exit with
+	ExitCode int
+	// The error returned by Run
+	Err error
+}
+
+// Run runs app with inv and returns the result. As with RunAndExitOnError, an
+// error returned by Run is written to Stderr unless a plugin or shell alias
+// already reported it. HelpPrinter, VersionPrinter, the help templates,
+// os.Args and everything inv changes are restored afterwards.
+func Run(t testing.TB, app *cli.App, inv Invocation) *Result {
+	t.Helper()
+	mu.Lock()
+	defer mu.Unlock()
+
+	defer restoreGlobals()()
+
+	for name, value := range inv.Env {
+		old, ok := os.LookupEnv(name)
+		os.Setenv(name, value)
+		if ok {
+			defer os.Setenv(name, old)
+		} else {
+			defer os.Unsetenv(name)
+		}
+	}
+
+	if inv.Dir != "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(inv.Dir); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chdir(wd)
+	}
+
+	stdin, err := ioutil.TempFile("", "clitest-stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stdin.Name())
+	defer stdin.Close()
+	if _, err := io.WriteString(stdin, inv.Stdin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	stdoutFile, waitStdout := capture(t, &stdout)
+	stderrFile, waitStderr := capture(t, &stderr)
+
+	oldStdin, oldStdout, oldStderr := os.Stdin, os.Stdout, os.Stderr
+	oldReader, oldWriter, oldErrWriter := app.Reader, app.Writer, app.ErrWriter
+	os.Stdin, os.Stdout, os.Stderr = stdin, stdoutFile, stderrFile
+	if app.Reader == nil || app.Reader == oldStdin {
+		app.Reader = stdin
+	}
+	if app.Writer == nil || app.Writer == oldStdout {
+		app.Writer = stdoutFile
+	}
+	if app.ErrWriter == nil || app.ErrWriter == oldStderr {
+		app.ErrWriter = stderrFile
+	}
+
+	res := &Result{}
+	func() {
+		defer func() {
+			os.Stdin, os.Stdout, os.Stderr = oldStdin, oldStdout, oldStderr
+			app.Reader, app.Writer, app.ErrWriter = oldReader, oldWriter, oldErrWriter
+		}()
+		os.Args = append([]string{app.Name}, inv.Args...)
+		res.Err = app.Run(os.Args)
+		if res.Err != nil {
+			res.ExitCode = 1
+			if e, ok := res.Err.(interface{ ExitCode() int }); ok {
+				res.ExitCode = e.ExitCode()
+			} else {
+				fmt.Fprintln(stderrFile, res.Err)
+			}
+		}
+	}()
+
+	stdoutFile.Close()
+	stderrFile.Close()
+	waitStdout()
+	waitStderr()
+	res.Stdout = stdout.String()
+	res.Stderr = stderr.String()
+	return res
+}
+
+// capture returns a file whose writes end up in buf once wait returns, which
+// must be after the file is closed.
+func capture(t testing.TB, buf *bytes.Buffer) (w *os.File, wait func()) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		io.Copy(buf, r)
+		r.Close()
+		close(done)
+	}()
+	return w, func() { <-done }
+}
+
+// restoreGlobals saves the package variables of cli that tests tend to
+// replace and returns a function that restores them.
+func restoreGlobals() func() {
+	helpPrinter, versionPrinter := cli.HelpPrinter, cli.VersionPrinter
+	appHelp, commandHelp, subcommandHelp := cli.AppHelpTemplate, cli.CommandHelpTemplate, cli.SubcommandHelpTemplate
+	args := os.Args
+	return func() {
+		cli.HelpPrinter, cli.VersionPrinter = helpPrinter, versionPrinter
+		cli.AppHelpTemplate, cli.CommandHelpTemplate, cli.SubcommandHelpTemplate = appHelp, commandHelp, subcommandHelp
+		os.Args = args
+	}
+}
+
+// AssertGolden compares actual with the file testdata/<name>.golden and fails
+// t if they differ. With the -update-golden test flag it writes actual to the
+// file instead.
+func AssertGolden(t testing.TB, name string, actual string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run the tests with -update-golden to create it", err)
+	}
+	if string(expected) != actual {
+		t.Errorf("output differs from %s; run the tests with -update-golden to accept it\n--- expected\n%s\n--- actual\n%s", path, expected, actual)
+	}
+}
+
+// CheckExamples fails t for every example of the commands of app whose
+// command line does not parse, as reported by App.CheckExamples.
+func CheckExamples(t testing.TB, app *cli.App) {
+	t.Helper()
+	for _, err := range app.CheckExamples() {
+		t.Error(err)
+	}
+}
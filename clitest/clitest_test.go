@@ -0,0 +1,116 @@
+package clitest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/davelaursen/cli"
+)
+
+func greetApp() *cli.App {
+	app := cli.NewApp()
+	app.Name = "greet"
+	app.Exec = "greet"
+	app.Usage = "greet [options] <command>"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "name", Value: "bob", EnvVar: "GREET_NAME", Description: "a name to say"},
+	}
+	app.Action = func(c *cli.Context) {
+		fmt.Printf("Hello %v\n", c.String("name"))
+	}
+	app.Commands = []cli.Command{
+		{
+			Name:             "echo",
+			ShortDescription: "prints stdin",
+			Examples: []cli.Example{
+				{Description: "Print stdin", Command: "greet --name Jo echo"},
+			},
+			Action: func(c *cli.Context) {
+				data, _ := ioutil.ReadAll(os.Stdin)
+				wd, _ := os.Getwd()
+				fmt.Printf("%s in %s", data, wd)
+			},
+		},
+		{
+			Name:             "fail",
+			ShortDescription: "fails",
+			Before: func(c *cli.Context) error {
+				fmt.Fprintln(c.App.ErrWriter, "about to fail")
+				return errors.New("failed")
+			},
+			Action: func(c *cli.Context) {},
+		},
+		{
+			Name:             "mangle",
+			ShortDescription: "replaces the help printer",
+			Action: func(c *cli.Context) {
+				cli.HelpPrinter = nil
+			},
+		},
+	}
+	return app
+}
+
+func TestRun(t *testing.T) {
+	res := Run(t, greetApp(), Invocation{
+		Args: []string{"--name", "Jo"},
+	})
+	expect(t, res.Stdout, "Hello Jo\n")
+	expect(t, res.Stderr, "")
+	expect(t, res.ExitCode, 0)
+	expect(t, res.Err, nil)
+}
+
+func TestRunEnvStdinAndDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, _ = filepath.EvalSymlinks(dir)
+
+	os.Unsetenv("GREET_NAME")
+	res := Run(t, greetApp(), Invocation{Env: map[string]string{"GREET_NAME": "Ann"}})
+	expect(t, res.Stdout, "Hello Ann\n")
+	_, ok := os.LookupEnv("GREET_NAME")
+	expect(t, ok, false)
+
+	res = Run(t, greetApp(), Invocation{
+		Args:  []string{"echo"},
+		Stdin: "some input",
+		Dir:   dir,
+	})
+	expect(t, res.Stdout, "some input in "+dir)
+}
+
+func TestRunError(t *testing.T) {
+	res := Run(t, greetApp(), Invocation{Args: []string{"fail"}})
+	expect(t, res.Stdout, "")
+	expect(t, res.Stderr, "about to fail\nfailed\n")
+	expect(t, res.ExitCode, 1)
+	expect(t, res.Err.Error(), "failed")
+}
+
+func TestRunRestoresGlobals(t *testing.T) {
+	Run(t, greetApp(), Invocation{Args: []string{"mangle"}})
+	if cli.HelpPrinter == nil {
+		t.Error("expected HelpPrinter to be restored")
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	res := Run(t, greetApp(), Invocation{Args: []string{"help"}})
+	AssertGolden(t, "help", res.Stdout)
+	if !strings.Contains(res.Stdout, "echo") {
+		t.Errorf("expected help output, got %q", res.Stdout)
+	}
+}
+
+func TestCheckExamples(t *testing.T) {
+	CheckExamples(t, greetApp())
+}
@@ -15,37 +15,86 @@ type Command struct {
 	Usage string
 	// A longer explanation of how the command works
 	Description string
+	// Examples of how the command is used, shown in its help
+	Examples []Example
+	// If set, the command is deprecated and hidden from help. The message should
+	// point at the replacement, e.g. "use 'app cluster' instead"
+	Deprecated string
 	// An action to execute before any subcommands are run, but after the context is ready
-	// If a non-nil error is returned, no subcommands are run
+	// If a non-nil error is returned, neither the subcommand nor Action is run
+	// and Run returns the error. Before was not called by earlier versions
 	Before func(context *Context) error
 	// The function to call when this command is invoked without a subcommand
 	Action func(context *Context)
+	// An action to execute after the subcommand or Action has run, if Before succeeded
+	After func(context *Context) error
 	// List of child commands
 	Subcommands []Subcommand
 	// List of flags to parse
 	Flags []Flag
+
+	// used instead of Action by built-in commands that can fail
+	run func(context *Context) error
 }
 
 // Run invokes the command given the context. It parses ctx.Args() to generate command-specific flags.
-func (c Command) Run(ctx *Context) error {
-	set := flagSet(c.Name, c.Flags)
+func (c Command) Run(ctx *Context) (err error) {
+	if c.Deprecated != "" {
+		if err := ctx.App.deprecated("command '"+c.Name+"'", c.Deprecated); err != nil {
+			return err
+		}
+	}
+
+	flags := ctx.App.envPrefixed(c.Flags, c.Name)
+	set, err := flagSet(c.Name, flags, ctx.App.reader())
+	if err != nil {
+		return err
+	}
 	set.SetOutput(ioutil.Discard)
-	err := set.Parse(ctx.Args()[1:])
+	err = set.Parse(ctx.Args()[1:])
 	if err != nil {
-		fmt.Printf("Incorrect Usage - type '%s help' for info\n\n", ctx.App.Exec)
+		fmt.Fprintf(ctx.App.writer(), ctx.App.message("incorrect-usage")+"\n\n", ctx.App.Exec)
+		return err
+	}
+
+	if err := ctx.App.checkDeprecatedFlags(c.Flags, set); err != nil {
 		return err
 	}
 
-	nerr := normalizeFlags(c.Flags, set)
+	nerr := ctx.App.normalizeFlags(c.Flags, set)
 	if nerr != nil {
-		fmt.Println(nerr)
-		fmt.Println("")
+		fmt.Fprintln(ctx.App.writer(), nerr)
+		fmt.Fprintln(ctx.App.writer(), "")
 		ShowCommandHelp(ctx, c.Name)
-		fmt.Println("")
+		fmt.Fprintln(ctx.App.writer(), "")
 		return nerr
 	}
 
 	context := NewContext(ctx.App, set, ctx.globalSet)
+	context.Command = c
+	context.ctx = ctx.ctx
+	context.logger = ctx.logger
+	context.commandPath = c.Name
+	context.lineRunner = ctx.lineRunner
+
+	if err := context.promptFlags(flags, ctx.App.isInteractive()); err != nil {
+		return err
+	}
+
+	if c.Before != nil {
+		if err := c.Before(context); err != nil {
+			return err
+		}
+	}
+
+	if c.After != nil {
+		defer func() {
+			if aerr := c.After(context); aerr != nil && err == nil {
+				err = aerr
+			}
+		}()
+	}
+
 	if len(c.Subcommands) > 0 && len(set.Args()) > 0 {
 		name := set.Args()[0]
 		s := c.Subcommand(name)
@@ -54,7 +103,12 @@ func (c Command) Run(ctx *Context) error {
 		}
 	}
 
-	context.Command = c
+	finished := context.logRun()
+	defer func() { finished(err) }()
+
+	if c.run != nil {
+		return c.run(context)
+	}
 	c.Action(context)
 	return nil
 }
@@ -85,6 +139,11 @@ type Subcommand struct {
 	Usage string
 	// A longer explanation of how the command works
 	Description string
+	// Examples of how the command is used, shown in its help
+	Examples []Example
+	// If set, the subcommand is deprecated and hidden from help. The message should
+	// point at the replacement
+	Deprecated string
 	// The function to call when this command is invoked without a subcommand
 	Action func(context *Context)
 	// List of flags to parse
@@ -92,27 +151,52 @@ type Subcommand struct {
 }
 
 // Run invokes the command given the context. It parses ctx.Args() to generate command-specific flags.
+// ctx.Command is expected to be the parent command.
 func (s Subcommand) Run(ctx *Context) error {
-	set := flagSet(s.Name, s.Flags)
+	if s.Deprecated != "" {
+		if err := ctx.App.deprecated("command '"+ctx.Command.Name+" "+s.Name+"'", s.Deprecated); err != nil {
+			return err
+		}
+	}
+
+	flags := ctx.App.envPrefixed(s.Flags, ctx.Command.Name, s.Name)
+	set, err := flagSet(s.Name, flags, ctx.App.reader())
+	if err != nil {
+		return err
+	}
 	set.SetOutput(ioutil.Discard)
-	err := set.Parse(ctx.Args()[1:])
+	err = set.Parse(ctx.Args()[1:])
 	if err != nil {
-		fmt.Printf("Incorrect Usage - type '%s help' for info\n\n", ctx.App.Exec)
+		fmt.Fprintf(ctx.App.writer(), ctx.App.message("incorrect-usage")+"\n\n", ctx.App.Exec)
+		return err
+	}
+
+	if err := ctx.App.checkDeprecatedFlags(s.Flags, set); err != nil {
 		return err
 	}
 
-	nerr := normalizeFlags(s.Flags, set)
+	nerr := ctx.App.normalizeFlags(s.Flags, set)
 	if nerr != nil {
-		fmt.Println(nerr)
-		fmt.Println("")
+		fmt.Fprintln(ctx.App.writer(), nerr)
+		fmt.Fprintln(ctx.App.writer(), "")
 		ShowCommandHelp(ctx, s.Name)
-		fmt.Println("")
+		fmt.Fprintln(ctx.App.writer(), "")
 		return nerr
 	}
 
 	context := NewContext(ctx.App, set, ctx.globalSet)
 	context.Command = subcmdToCmd(s)
+	context.ctx = ctx.ctx
+	context.logger = ctx.logger
+	context.commandPath = ctx.commandPath + " " + s.Name
+	context.lineRunner = ctx.lineRunner
+
+	if err := context.promptFlags(flags, ctx.App.isInteractive()); err != nil {
+		return err
+	}
+	finished := context.logRun()
 	s.Action(context)
+	finished(nil)
 	return nil
 }
 
@@ -127,6 +211,8 @@ func subcmdToCmd(s Subcommand) Command {
 		ShortDescription: s.ShortDescription,
 		Usage:            s.Usage,
 		Description:      s.Description,
+		Examples:         s.Examples,
+		Deprecated:       s.Deprecated,
 		Action:           s.Action,
 		Flags:            s.Flags,
 	}
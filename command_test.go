@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"testing"
 )
@@ -24,3 +25,47 @@ func TestCommand(t *testing.T) {
 
 	expect(t, err.Error(), "flag provided but not defined: -break")
 }
+
+func TestCommand_BeforeRunsBeforeAction(t *testing.T) {
+	var calls []string
+	app := NewApp()
+	app.Commands = []Command{
+		{
+			Name: "deploy",
+			Before: func(c *Context) error {
+				calls = append(calls, "before "+c.String("env"))
+				return nil
+			},
+			Action: func(c *Context) {
+				calls = append(calls, "action")
+			},
+			Flags: []Flag{StringFlag{Name: "env"}},
+		},
+	}
+
+	expect(t, app.Run([]string{"app", "deploy", "--env", "prod"}), nil)
+	expect(t, len(calls), 2)
+	expect(t, calls[0], "before prod")
+	expect(t, calls[1], "action")
+}
+
+func TestCommand_BeforeErrorStopsCommand(t *testing.T) {
+	actionRun, subcommandRun := false, false
+	beforeErr := errors.New("not logged in")
+	app := NewApp()
+	app.Commands = []Command{
+		{
+			Name:   "deploy",
+			Before: func(c *Context) error { return beforeErr },
+			Action: func(c *Context) { actionRun = true },
+			Subcommands: []Subcommand{
+				{Name: "rollback", Action: func(c *Context) { subcommandRun = true }},
+			},
+		},
+	}
+
+	expect(t, app.Run([]string{"app", "deploy"}), beforeErr)
+	expect(t, app.Run([]string{"app", "deploy", "rollback"}), beforeErr)
+	expect(t, actionRun, false)
+	expect(t, subcommandRun, false)
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// completer is implemented by flags that only accept a known set of values,
+// so that shell completion can offer them.
+type completer interface {
+	completions() []string
+}
+
+// boolFlag is implemented by flag.Value types that do not take an argument.
+type boolFlag interface {
+	IsBoolFlag() bool
+}
+
+// isCompletionRequest reports whether the arguments end with the
+// BashCompletionFlag and the App has bash completion enabled.
+func (a *App) isCompletionRequest(arguments []string) bool {
+	return a.EnableBashCompletion && len(arguments) > 1 &&
+		arguments[len(arguments)-1] == "--"+BashCompletionFlag.Name
+}
+
+// printCompletions prints one completion candidate per line for the word
+// following the given arguments.
+func (a *App) printCompletions(args []string) {
+	for _, candidate := range a.completions(args) {
+		fmt.Fprintln(a.writer(), candidate)
+	}
+}
+
+// completions returns the candidates for the word following args, which are the
+// arguments given after the program name. If the last argument is a flag that
+// takes a value, the values offered by that flag are returned. Otherwise the
+// commands or subcommands and the flags in scope are returned.
+func (a *App) completions(args []string) []string {
+	flags := a.flags()
+	names := commandNames(visibleCommands(a.commands()))
+	var command *Command
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			if strings.Contains(arg, "=") {
+				continue
+			}
+			f := findFlag(flags, strings.TrimLeft(arg, "-"))
+			if f == nil || !takesValue(f) {
+				continue
+			}
+			if i == len(args)-1 {
+				if c, ok := f.(completer); ok {
+					return c.completions()
+				}
+				return nil
+			}
+			i++
+			continue
+		}
+		if command == nil {
+			if command = a.Command(arg); command != nil {
+				flags = command.Flags
+				names = subcommandNames(visibleSubcommands(command.Subcommands))
+				continue
+			}
+		} else if s := command.Subcommand(arg); s != nil {
+			flags = s.Flags
+			names = nil
+			continue
+		}
+	}
+
+	candidates := names
+	for _, f := range visibleFlags(flags) {
+		eachName(f.getName(), func(name string) {
+			candidates = append(candidates, "--"+name)
+		})
+	}
+	return candidates
+}
+
+// findFlag returns the flag with the given name, or nil.
+func findFlag(flags []Flag, name string) Flag {
+	for _, f := range flags {
+		found := false
+		eachName(f.getName(), func(n string) {
+			if n == name {
+				found = true
+			}
+		})
+		if found {
+			return f
+		}
+	}
+	return nil
+}
+
+// takesValue reports whether a flag expects an argument.
+func takesValue(f Flag) bool {
+	set := flag.NewFlagSet("", flag.ContinueOnError)
+	f.Apply(set)
+	var isBool bool
+	set.VisitAll(func(ff *flag.Flag) {
+		if b, ok := ff.Value.(boolFlag); ok && b.IsBoolFlag() {
+			isBool = true
+		}
+	})
+	return !isBool
+}
+
+func commandNames(commands []Command) (names []string) {
+	for _, c := range commands {
+		names = append(names, c.Name)
+	}
+	return
+}
+
+func subcommandNames(subcommands []Subcommand) (names []string) {
+	for _, s := range subcommands {
+		names = append(names, s.Name)
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+var completionTests = []struct {
+	args     []string
+	expected []string
+}{
+	{[]string{}, []string{"remote", "help", "--output", "--o", "--debug", "--version"}},
+	{[]string{"-o"}, []string{"json", "yaml", "table"}},
+	{[]string{"--debug"}, []string{"remote", "help", "--output", "--o", "--debug", "--version"}},
+	{[]string{"-o", "json", "remote"}, []string{"add", "remove", "--name"}},
+	{[]string{"remote", "--name"}, nil},
+	{[]string{"remote", "add", "--protocol"}, []string{"ssh", "https"}},
+	{[]string{"remote", "add"}, []string{"--protocol"}},
+}
+
+func TestApp_Completions(t *testing.T) {
+	app := NewApp()
+	app.Flags = []Flag{
+		ChoiceFlag{Name: "output, o", Allowed: []string{"json", "yaml", "table"}},
+		BoolFlag{Name: "debug"},
+	}
+	app.Commands = []Command{
+		{
+			Name:  "remote",
+			Flags: []Flag{StringFlag{Name: "name"}},
+			Subcommands: []Subcommand{
+				{Name: "add", Flags: []Flag{ChoiceFlag{Name: "protocol", Allowed: []string{"ssh", "https"}}}},
+				{Name: "remove"},
+			},
+		},
+	}
+
+	for _, test := range completionTests {
+		actual := app.completions(test.args)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("completions for %v: expected %v, got %v", test.args, test.expected, actual)
+		}
+	}
+}
+
+func TestApp_RunCompletionRequest(t *testing.T) {
+	actionRun := false
+	app := NewApp()
+	app.EnableBashCompletion = true
+	app.Flags = []Flag{
+		ChoiceFlag{Name: "output, o", Allowed: []string{"json", "yaml", "table"}},
+	}
+	app.Action = func(c *Context) {
+		actionRun = true
+	}
+
+	err := app.Run([]string{"app", "-o", "--generate-bash-completion"})
+	expect(t, err, nil)
+	expect(t, actionRun, false)
+}
@@ -1,8 +1,14 @@
 package cli
 
 import (
+	gocontext "context"
 	"errors"
 	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +24,14 @@ type Context struct {
 	flagSet   *flag.FlagSet
 	globalSet *flag.FlagSet
 	setFlags  map[string]bool
+	ctx       gocontext.Context
+	// the logger configured by the logging flags, if App.EnableLogging is set
+	logger *slog.Logger
+	// the names of the running command and subcommand
+	commandPath string
+	// the built-in command running this line, shell or run-script, if any. For a
+	// script run from the shell it is run-script
+	lineRunner string
 }
 
 // Creates a new context. For use in when invoking an App or Command action.
@@ -25,6 +39,15 @@ func NewContext(app *App, set *flag.FlagSet, globalSet *flag.FlagSet) *Context {
 	return &Context{App: app, flagSet: set, globalSet: globalSet}
 }
 
+// Context returns the context.Context given to App.RunContext, which is cancelled when
+// the action should stop. It is never nil.
+func (c *Context) Context() gocontext.Context {
+	if c.ctx == nil {
+		return gocontext.Background()
+	}
+	return c.ctx
+}
+
 // Looks up the value of a local int flag, returns 0 if no int flag exists
 func (c *Context) Int(name string) int {
 	return lookupInt(name, c.flagSet)
@@ -50,6 +73,11 @@ func (c *Context) BoolT(name string) bool {
 	return lookupBoolT(name, c.flagSet)
 }
 
+// Looks up how often a local count flag was given, returns 0 if no count flag exists
+func (c *Context) Count(name string) int {
+	return lookupInt(name, c.flagSet)
+}
+
 // Looks up the value of a local string flag, returns "" if no string flag exists
 func (c *Context) String(name string) string {
 	return lookupString(name, c.flagSet)
@@ -60,6 +88,56 @@ func (c *Context) Generic(name string) interface{} {
 	return lookupGeneric(name, c.flagSet)
 }
 
+// Looks up the value of a local int64 flag, returns 0 if no int64 flag exists
+func (c *Context) Int64(name string) int64 {
+	return lookupInt64(name, c.flagSet)
+}
+
+// Looks up the value of a local uint flag, returns 0 if no uint flag exists
+func (c *Context) Uint(name string) uint {
+	return lookupUint(name, c.flagSet)
+}
+
+// Looks up the value of a local uint64 flag, returns 0 if no uint64 flag exists
+func (c *Context) Uint64(name string) uint64 {
+	return lookupUint64(name, c.flagSet)
+}
+
+// Looks up the value of a local timestamp flag, returns the zero time if no timestamp flag exists
+func (c *Context) Timestamp(name string) time.Time {
+	return lookupTimestamp(name, c.flagSet)
+}
+
+// Looks up the value of a local byte size flag, returns 0 if no byte size flag exists
+func (c *Context) ByteSize(name string) ByteSize {
+	return lookupByteSize(name, c.flagSet)
+}
+
+// Looks up the value of a local URL flag, returns nil if no URL flag exists
+func (c *Context) URL(name string) *url.URL {
+	return lookupURL(name, c.flagSet)
+}
+
+// Looks up the value of a local IP flag, returns nil if no IP flag exists
+func (c *Context) IP(name string) net.IP {
+	return lookupIP(name, c.flagSet)
+}
+
+// Looks up the value of a local CIDR flag, returns nil if no CIDR flag exists
+func (c *Context) CIDR(name string) *net.IPNet {
+	return lookupCIDR(name, c.flagSet)
+}
+
+// Looks up the value of a local regexp flag, returns nil if no regexp flag exists
+func (c *Context) Regexp(name string) *regexp.Regexp {
+	return lookupRegexp(name, c.flagSet)
+}
+
+// Looks up the values of a local choice slice flag, returns nil if no choice slice flag exists
+func (c *Context) ChoiceSlice(name string) []string {
+	return lookupChoiceSlice(name, c.flagSet)
+}
+
 // Looks up the value of a global int flag, returns 0 if no int flag exists
 func (c *Context) GlobalInt(name string) int {
 	return lookupInt(name, c.globalSet)
@@ -75,6 +153,11 @@ func (c *Context) GlobalBool(name string) bool {
 	return lookupBool(name, c.globalSet)
 }
 
+// Looks up how often a global count flag was given, returns 0 if no count flag exists
+func (c *Context) GlobalCount(name string) int {
+	return lookupInt(name, c.globalSet)
+}
+
 // Looks up the value of a global string flag, returns "" if no string flag exists
 func (c *Context) GlobalString(name string) string {
 	return lookupString(name, c.globalSet)
@@ -85,12 +168,70 @@ func (c *Context) GlobalGeneric(name string) interface{} {
 	return lookupGeneric(name, c.globalSet)
 }
 
+// Looks up the value of a global int64 flag, returns 0 if no int64 flag exists
+func (c *Context) GlobalInt64(name string) int64 {
+	return lookupInt64(name, c.globalSet)
+}
+
+// Looks up the value of a global uint flag, returns 0 if no uint flag exists
+func (c *Context) GlobalUint(name string) uint {
+	return lookupUint(name, c.globalSet)
+}
+
+// Looks up the value of a global uint64 flag, returns 0 if no uint64 flag exists
+func (c *Context) GlobalUint64(name string) uint64 {
+	return lookupUint64(name, c.globalSet)
+}
+
+// Looks up the value of a global timestamp flag, returns the zero time if no timestamp flag exists
+func (c *Context) GlobalTimestamp(name string) time.Time {
+	return lookupTimestamp(name, c.globalSet)
+}
+
+// Looks up the value of a global byte size flag, returns 0 if no byte size flag exists
+func (c *Context) GlobalByteSize(name string) ByteSize {
+	return lookupByteSize(name, c.globalSet)
+}
+
+// Looks up the value of a global URL flag, returns nil if no URL flag exists
+func (c *Context) GlobalURL(name string) *url.URL {
+	return lookupURL(name, c.globalSet)
+}
+
+// Looks up the value of a global IP flag, returns nil if no IP flag exists
+func (c *Context) GlobalIP(name string) net.IP {
+	return lookupIP(name, c.globalSet)
+}
+
+// Looks up the value of a global CIDR flag, returns nil if no CIDR flag exists
+func (c *Context) GlobalCIDR(name string) *net.IPNet {
+	return lookupCIDR(name, c.globalSet)
+}
+
+// Looks up the value of a global regexp flag, returns nil if no regexp flag exists
+func (c *Context) GlobalRegexp(name string) *regexp.Regexp {
+	return lookupRegexp(name, c.globalSet)
+}
+
+// Looks up the values of a global choice slice flag, returns nil if no choice slice flag exists
+func (c *Context) GlobalChoiceSlice(name string) []string {
+	return lookupChoiceSlice(name, c.globalSet)
+}
+
 // Determines if the flag was actually set exists
 func (c *Context) IsSet(name string) bool {
 	if c.setFlags == nil {
 		c.setFlags = make(map[string]bool)
 		c.flagSet.Visit(func(f *flag.Flag) {
 			c.setFlags[f.Name] = true
+			// --no-<name> sets every form of the negated flag
+			if nv, ok := f.Value.(*negatedBoolValue); ok {
+				c.flagSet.VisitAll(func(g *flag.Flag) {
+					if bv, ok := g.Value.(*boolValue); ok && bv == (*boolValue)(nv) {
+						c.setFlags[g.Name] = true
+					}
+				})
+			}
 		})
 	}
 	return c.setFlags[name] == true
@@ -202,11 +343,110 @@ func lookupString(name string, set *flag.FlagSet) string {
 func lookupGeneric(name string, set *flag.FlagSet) interface{} {
 	f := set.Lookup(name)
 	if f != nil {
+		if fv, ok := f.Value.(*fileValue); ok {
+			return fv.Value
+		}
 		return f.Value
 	}
 	return nil
 }
 
+func lookupInt64(name string, set *flag.FlagSet) int64 {
+	f := set.Lookup(name)
+	if f != nil {
+		val, err := strconv.ParseInt(f.Value.String(), 0, 64)
+		if err != nil {
+			return 0
+		}
+		return val
+	}
+
+	return 0
+}
+
+func lookupUint(name string, set *flag.FlagSet) uint {
+	f := set.Lookup(name)
+	if f != nil {
+		val, err := strconv.ParseUint(f.Value.String(), 0, strconv.IntSize)
+		if err != nil {
+			return 0
+		}
+		return uint(val)
+	}
+
+	return 0
+}
+
+func lookupUint64(name string, set *flag.FlagSet) uint64 {
+	f := set.Lookup(name)
+	if f != nil {
+		val, err := strconv.ParseUint(f.Value.String(), 0, 64)
+		if err != nil {
+			return 0
+		}
+		return val
+	}
+
+	return 0
+}
+
+func lookupByteSize(name string, set *flag.FlagSet) ByteSize {
+	f := set.Lookup(name)
+	if f != nil {
+		val, err := ParseByteSize(f.Value.String())
+		if err != nil {
+			return 0
+		}
+		return val
+	}
+
+	return 0
+}
+
+func lookupTimestamp(name string, set *flag.FlagSet) time.Time {
+	if val, ok := lookupGetter(name, set).(time.Time); ok {
+		return val
+	}
+	return time.Time{}
+}
+
+func lookupURL(name string, set *flag.FlagSet) *url.URL {
+	val, _ := lookupGetter(name, set).(*url.URL)
+	return val
+}
+
+func lookupIP(name string, set *flag.FlagSet) net.IP {
+	val, _ := lookupGetter(name, set).(net.IP)
+	return val
+}
+
+func lookupCIDR(name string, set *flag.FlagSet) *net.IPNet {
+	val, _ := lookupGetter(name, set).(*net.IPNet)
+	return val
+}
+
+func lookupRegexp(name string, set *flag.FlagSet) *regexp.Regexp {
+	val, _ := lookupGetter(name, set).(*regexp.Regexp)
+	return val
+}
+
+func lookupChoiceSlice(name string, set *flag.FlagSet) []string {
+	val, _ := lookupGetter(name, set).([]string)
+	return val
+}
+
+// lookupGetter returns the underlying value of a flag whose flag.Value
+// implements flag.Getter, or nil.
+func lookupGetter(name string, set *flag.FlagSet) interface{} {
+	f := set.Lookup(name)
+	if f != nil {
+		if g, ok := f.Value.(flag.Getter); ok {
+			return g.Get()
+		}
+	}
+	return nil
+}
+
 func lookupBool(name string, set *flag.FlagSet) bool {
 	f := set.Lookup(name)
 	if f != nil {
@@ -234,10 +474,14 @@ func lookupBoolT(name string, set *flag.FlagSet) bool {
 }
 
 func copyFlag(name string, ff *flag.Flag, set *flag.FlagSet) {
+	if fv, ok := set.Lookup(name).Value.(*fileValue); ok {
+		fv.literal = true
+		defer func() { fv.literal = false }()
+	}
 	set.Set(name, ff.Value.String())
 }
 
-func normalizeFlags(flags []Flag, set *flag.FlagSet) error {
+func (a *App) normalizeFlags(flags []Flag, set *flag.FlagSet) error {
 	visited := make(map[string]bool)
 	set.Visit(func(f *flag.Flag) {
 		visited[f.Name] = true
@@ -252,7 +496,7 @@ func normalizeFlags(flags []Flag, set *flag.FlagSet) error {
 			name = strings.Trim(name, " ")
 			if visited[name] {
 				if ff != nil {
-					return errors.New("Cannot use two forms of the same flag: " + name + " " + ff.Name)
+					return fmt.Errorf(a.message("two-forms"), name, ff.Name)
 				}
 				ff = set.Lookup(name)
 			}
@@ -73,3 +73,35 @@ func TestContext_IsSet(t *testing.T) {
 	expect(t, c.IsSet("otherflag"), false)
 	expect(t, c.IsSet("bogusflag"), false)
 }
+
+func TestContext_Int64(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	v := int64Value(-12)
+	set.Var(&v, "myflag", "doc")
+	globalSet := flag.NewFlagSet("test", 0)
+	gv := int64Value(42)
+	globalSet.Var(&gv, "myflag", "doc")
+	c := NewContext(nil, set, globalSet)
+	expect(t, c.Int64("myflag"), int64(-12))
+	expect(t, c.GlobalInt64("myflag"), int64(42))
+}
+
+func TestContext_Timestamp(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	ts := time.Date(2014, 3, 1, 0, 0, 0, 0, time.UTC)
+	set.Var(&timestampValue{time: ts, layout: time.RFC3339}, "myflag", "doc")
+	c := NewContext(nil, set, set)
+	expect(t, c.Timestamp("myflag"), ts)
+	expect(t, c.Timestamp("bogusflag").IsZero(), true)
+	expect(t, c.URL("bogusflag") == nil, true)
+}
+
+func TestContext_Count(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	v := countValue(0)
+	set.Var(&v, "myflag", "doc")
+	c := NewContext(nil, set, set)
+	set.Parse([]string{"-myflag", "-myflag"})
+	expect(t, c.Count("myflag"), 2)
+	expect(t, c.GlobalCount("myflag"), 2)
+}
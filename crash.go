@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// PanicError is returned by App.Run when RecoverPanics is set and a Before,
+// Action or After function panics.
+type PanicError struct {
+	// The value passed to panic
+	Value interface{}
+	// The stack of the goroutine that panicked
+	Stack []byte
+	// Where the crash report was written, if it was
+	ReportPath string
+	// Why the crash report could not be written, if it could not
+	ReportErr error
+
+	// the App that panicked, whose messages are used
+	app *App
+}
+
+func (e *PanicError) Error() string {
+	a := e.app
+	if a == nil {
+		a = &App{}
+	}
+	msg := fmt.Sprintf(a.message("crashed"), a.Name, e.Value)
+	if e.ReportPath != "" {
+		msg += "\n" + fmt.Sprintf(a.message("crash-report"), e.ReportPath)
+	} else if e.ReportErr != nil {
+		msg += "\n" + fmt.Sprintf(a.message("crash-report-error"), e.ReportErr)
+	}
+	return msg
+}
+
+// recoverPanic turns a panic into a *PanicError stored in err and writes a
+// crash report to CrashReportDir if it is set. It must be deferred.
+func (a *App) recoverPanic(arguments []string, err *error) {
+	if !a.RecoverPanics {
+		return
+	}
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	perr := &PanicError{Value: r, Stack: debug.Stack(), app: a}
+	if a.CrashReportDir != "" {
+		perr.ReportPath, perr.ReportErr = a.writeCrashReport(arguments, perr)
+	}
+	*err = perr
+}
+
+func (a *App) writeCrashReport(arguments []string, perr *PanicError) (string, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s crash report\n\n", a.Name)
+	fmt.Fprintf(&b, "Version:  %s\n", a.Version)
+	fmt.Fprintf(&b, "Compiled: %s\n", a.Compiled.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Time:     %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Go:       %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Args:     %s\n", strings.Join(a.redactArgs(arguments), " "))
+	fmt.Fprintf(&b, "Panic:    %v\n\n", perr.Value)
+	b.Write(perr.Stack)
+
+	f, err := ioutil.TempFile(a.CrashReportDir, filepath.Base(a.Name)+"-crash-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(b.Bytes()); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// secretFlag is implemented by flags that can hold secrets, which are left
+// out of crash reports and of the environment of plugins.
+type secretFlag interface {
+	Flag
+	secret() bool
+}
+
+// redactArgs replaces the values of secret flags in arguments.
+func (a *App) redactArgs(arguments []string) []string {
+	secrets := make(map[string]bool)
+	addSecrets := func(flags []Flag) {
+		for _, f := range flags {
+			if sf, ok := f.(secretFlag); ok && sf.secret() {
+				eachName(f.getName(), func(name string) {
+					secrets[name] = true
+				})
+			}
+		}
+	}
+	addSecrets(a.Flags)
+	for _, c := range a.commands() {
+		addSecrets(c.Flags)
+		for _, s := range c.Subcommands {
+			addSecrets(s.Flags)
+		}
+	}
+
+	redacted := make([]string, len(arguments))
+	copy(redacted, arguments)
+	for i := 1; i < len(redacted); i++ {
+		arg := redacted[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if eq := strings.Index(name, "="); eq >= 0 {
+			if secrets[name[:eq]] {
+				redacted[i] = arg[:len(arg)-len(name)+eq+1] + "[REDACTED]"
+			}
+		} else if secrets[name] && i+1 < len(redacted) {
+			i++
+			redacted[i] = "[REDACTED]"
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApp_RecoverPanics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	app := NewApp()
+	app.Name = "greet"
+	app.Version = "1.2.3"
+	app.RecoverPanics = true
+	app.CrashReportDir = dir
+	app.Flags = []Flag{
+		StringFlag{Name: "token", Secret: true},
+	}
+	app.Action = func(c *Context) {
+		panic("boom")
+	}
+
+	err = app.Run([]string{"greet", "--token", "s3cr3t", "world"})
+	perr, ok := err.(*PanicError)
+	if !ok {
+		t.Fatalf("expected a *PanicError, got %v", err)
+	}
+	expect(t, perr.Value, "boom")
+	expect(t, strings.HasPrefix(perr.Error(), "greet crashed unexpectedly: boom\nA crash report was saved to "+dir), true)
+
+	report, err := ioutil.ReadFile(perr.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"Version:  1.2.3", "Args:     greet --token [REDACTED] world", "Panic:    boom", "TestApp_RecoverPanics"} {
+		if !strings.Contains(string(report), s) {
+			t.Errorf("crash report does not contain %q", s)
+		}
+	}
+	if strings.Contains(string(report), "s3cr3t") {
+		t.Errorf("crash report contains a secret")
+	}
+}
+
+func TestApp_RecoverPanicsInCommandBefore(t *testing.T) {
+	app := NewApp()
+	app.Name = "greet"
+	app.RecoverPanics = true
+	app.Commands = []Command{
+		{
+			Name: "hello",
+			Before: func(c *Context) error {
+				var m map[string]int
+				m["x"] = 1
+				return nil
+			},
+			Action: func(c *Context) {},
+		},
+	}
+
+	err := app.Run([]string{"greet", "hello"})
+	perr, ok := err.(*PanicError)
+	if !ok {
+		t.Fatalf("expected a *PanicError, got %v", err)
+	}
+	expect(t, perr.ReportPath, "")
+	expect(t, perr.Error(), "greet crashed unexpectedly: assignment to entry in nil map")
+}
+
+func TestApp_RedactArgs(t *testing.T) {
+	app := NewApp()
+	app.Flags = []Flag{
+		StringFlag{Name: "password, p", Secret: true},
+	}
+	app.Commands = []Command{
+		{
+			Name:  "login",
+			Flags: []Flag{GenericFlag{Name: "key", Value: &Parser{}, Secret: true}, StringFlag{Name: "user"}},
+		},
+	}
+
+	actual := app.redactArgs([]string{"app", "-p", "x", "login", "--key=a,b", "-user", "bob", "--", "-p", "y"})
+	expected := []string{"app", "-p", "[REDACTED]", "login", "--key=[REDACTED]", "-user", "bob", "--", "-p", "y"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
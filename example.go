@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// Example shows how a command or subcommand is used.
+type Example struct {
+	// What the example does
+	Description string
+	// The command line, starting with the program name
+	Command string
+}
+
+// CheckExamples parses the command line of every example of the commands and
+// subcommands against the flags of the App and of the command, without
+// running anything, and returns an error for each example that does not
+// parse or does not run the command it belongs to.
+func (a *App) CheckExamples() []error {
+	var errs []error
+	for _, c := range a.Commands {
+		for _, e := range c.Examples {
+			if err := a.checkExample(e, c, nil); err != nil {
+				errs = append(errs, fmt.Errorf("example %q of command '%s': %v", e.Command, c.Name, err))
+			}
+		}
+		for _, s := range c.Subcommands {
+			for _, e := range s.Examples {
+				if err := a.checkExample(e, c, &s); err != nil {
+					errs = append(errs, fmt.Errorf("example %q of command '%s %s': %v", e.Command, c.Name, s.Name, err))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// checkExample parses e as the App would when running command c, or its
+// subcommand s if that is not nil.
+func (a *App) checkExample(e Example, c Command, s *Subcommand) error {
+	args, err := tokenize(e.Command)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("empty command line")
+	}
+
+	if args, err = a.parseExample(a.Name, a.flags(), args[1:]); err != nil {
+		return err
+	}
+	if len(args) == 0 || !c.HasName(args[0]) {
+		return fmt.Errorf("does not run '%s'", c.Name)
+	}
+	if args, err = a.parseExample(c.Name, c.Flags, args[1:]); err != nil || s == nil {
+		return err
+	}
+	if len(args) == 0 || !s.HasName(args[0]) {
+		return fmt.Errorf("does not run '%s %s'", c.Name, s.Name)
+	}
+	_, err = a.parseExample(s.Name, s.Flags, args[1:])
+	return err
+}
+
+// parseExample parses flags from args and returns the arguments after them.
+func (a *App) parseExample(name string, flags []Flag, args []string) ([]string, error) {
+	set, err := flagSet(name, flags, a.reader())
+	if err != nil {
+		return nil, err
+	}
+	set.SetOutput(ioutil.Discard)
+	if err := set.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := a.normalizeFlags(flags, set); err != nil {
+		return nil, err
+	}
+	return set.Args(), nil
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestApp_CheckExamples(t *testing.T) {
+	app := NewApp()
+	app.Name = "git"
+	app.Flags = []Flag{BoolFlag{Name: "verbose"}}
+	app.Commands = []Command{
+		{
+			Name:  "remote",
+			Flags: []Flag{StringFlag{Name: "name"}},
+			Examples: []Example{
+				{Command: "git --verbose remote"},
+				{Command: "git remote --name origin"},
+			},
+			Subcommands: []Subcommand{
+				{
+					Name: "add",
+					Flags: []Flag{
+						ChoiceFlag{Name: "protocol", Allowed: []string{"ssh", "https"}},
+					},
+					Examples: []Example{
+						{Command: "git remote add --protocol ssh origin 'host:repo'"},
+					},
+				},
+			},
+		},
+	}
+	expect(t, len(app.CheckExamples()), 0)
+
+	remote := &app.Commands[0]
+	remote.Examples = []Example{
+		{Command: "git remote --nmae origin"},
+		{Command: "git status"},
+		{Command: "git remote 'unterminated"},
+	}
+	remote.Subcommands[0].Examples = []Example{
+		{Command: "git remote add --protocol ftp origin"},
+		{Command: "git remote list"},
+	}
+
+	errs := app.CheckExamples()
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	expect(t, len(errs), 5)
+	expect(t, messages[0], `example "git remote --nmae origin" of command 'remote': flag provided but not defined: -nmae`)
+	expect(t, messages[1], `example "git status" of command 'remote': does not run 'remote'`)
+	expect(t, strings.HasPrefix(messages[2], `example "git remote 'unterminated" of command 'remote': `), true)
+	expect(t, strings.HasPrefix(messages[3], `example "git remote add --protocol ftp origin" of command 'remote add': `), true)
+	expect(t, messages[4], `example "git remote list" of command 'remote add': does not run 'remote add'`)
+}
+
+func TestShowCommandHelp_Examples(t *testing.T) {
+	var out bytes.Buffer
+	app := NewApp()
+	app.Name = "git"
+	app.Writer = &out
+	app.Commands = []Command{
+		{
+			Name: "remote",
+			Examples: []Example{
+				{Description: "List the remotes", Command: "git --verbose remote"},
+				{Command: "git remote --name origin"},
+			},
+			Subcommands: []Subcommand{
+				{
+					Name: "add",
+					Examples: []Example{
+						{Description: "Add a remote over SSH", Command: "git remote add --protocol ssh origin 'host:repo'"},
+					},
+				},
+			},
+		},
+	}
+	app.Run([]string{"git", "help", "remote"})
+
+	expected := `EXAMPLES:
+   List the remotes
+      $ git --verbose remote
+
+      $ git remote --name origin`
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("expected help to contain %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	app.Run([]string{"git", "help", "remote", "add"})
+	expected = "EXAMPLES:\n   Add a remote over SSH\n      $ git remote add --protocol ssh origin 'host:repo'\n"
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("expected help to contain %q, got %q", expected, out.String())
+	}
+}
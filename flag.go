@@ -3,8 +3,12 @@ package cli
 import (
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
-	"strconv"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -30,13 +34,138 @@ type Flag interface {
 	getName() string
 }
 
-func flagSet(name string, flags []Flag) *flag.FlagSet {
+// fileValueFlag is implemented by flags that can opt in to reading their
+// value from a file or stdin.
+type fileValueFlag interface {
+	allowsFileValue() bool
+}
+
+// errorableFlag is implemented by the built-in flags, whose values from the
+// environment are checked with the same parser as on the command line.
+type errorableFlag interface {
+	applyWithError(*flag.FlagSet) error
+}
+
+// flagSet returns a flag set with flags applied. Flags with AllowFileValue read
+// a value of - from stdin.
+func flagSet(name string, flags []Flag, stdin io.Reader) (*flag.FlagSet, error) {
 	set := flag.NewFlagSet(name, flag.ContinueOnError)
 
 	for _, f := range flags {
-		f.Apply(set)
+		if ef, ok := f.(errorableFlag); ok {
+			if err := ef.applyWithError(set); err != nil {
+				return nil, err
+			}
+		} else {
+			f.Apply(set)
+		}
+		if ff, ok := f.(fileValueFlag); ok && ff.allowsFileValue() {
+			eachName(f.getName(), func(name string) {
+				if fl := set.Lookup(name); fl != nil {
+					fl.Value = &fileValue{Value: fl.Value, stdin: stdin}
+				}
+			})
+		}
 	}
-	return set
+	return set, nil
+}
+
+// deprecatedFlag is implemented by flags that can be deprecated. A non-empty
+// message, such as "use --output instead", deprecates the flag.
+type deprecatedFlag interface {
+	Flag
+	deprecated() string
+}
+
+// hiddenFlag is implemented by flags that can be left out of help.
+type hiddenFlag interface {
+	Flag
+	hidden() bool
+}
+
+// envFlag is implemented by flags that can be set from environment variables.
+type envFlag interface {
+	Flag
+	// withEnvVars returns a copy of the flag that also checks the given variables
+	withEnvVars(names ...string) Flag
+}
+
+// describedFlag is implemented by flags with a description, which can be
+// translated.
+type describedFlag interface {
+	Flag
+	// withDescription returns a copy of the flag with the given description
+	withDescription(description string) Flag
+}
+
+// envValue returns the value of the first of envVar and envVars that is set
+// or, if none is, the contents of filePath without trailing newlines. source
+// describes where the value came from. A file that does not exist is skipped,
+// but one that cannot be read is an error.
+func envValue(envVar string, envVars []string, filePath string) (value, source string, ok bool, err error) {
+	for _, name := range envVarNames(envVar, envVars) {
+		if envVal := os.Getenv(name); envVal != "" {
+			return envVal, "environment variable " + name, true, nil
+		}
+	}
+	if filePath != "" {
+		data, err := ioutil.ReadFile(filePath)
+		if err == nil {
+			return trimNewlines(string(data)), "file " + filePath, true, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "file " + filePath, true, err
+		}
+	}
+	return "", "", false, nil
+}
+
+// setFromEnv sets every form of a flag from its environment variables or
+// file, using the same flag.Value parser as the command line.
+func setFromEnv(set *flag.FlagSet, names, envVar string, envVars []string, filePath string) error {
+	envVal, source, ok, err := envValue(envVar, envVars, filePath)
+	if err != nil {
+		return envError(envVal, source, names, err)
+	}
+	if !ok {
+		return nil
+	}
+
+	var last flag.Value
+	eachName(names, func(name string) {
+		fl := set.Lookup(name)
+		// forms of a flag that share a value are only set once
+		if err != nil || fl == nil || fl.Value == last {
+			return
+		}
+		last = fl.Value
+		if serr := fl.Value.Set(envVal); serr != nil {
+			err = envError(envVal, source, names, serr)
+		}
+	})
+	return err
+}
+
+// envError reports a value from the environment that the flag could not parse.
+func envError(value, source, names string, err error) error {
+	name := strings.Trim(strings.Split(names, ",")[0], " ")
+	return fmt.Errorf("invalid value %q from %s for flag -%s: %v", value, source, name, err)
+}
+
+// envVarNames returns the environment variables of a flag in the order they are checked.
+func envVarNames(envVar string, envVars []string) []string {
+	if envVar == "" {
+		return envVars
+	}
+	return append([]string{envVar}, envVars...)
+}
+
+func appendEnvVars(envVars []string, names []string) []string {
+	return append(append([]string(nil), envVars...), names...)
+}
+
+func trimNewlines(s string) string {
+	return strings.TrimRight(s, "\r\n")
 }
 
 func eachName(longName string, fn func(string)) {
@@ -58,95 +187,272 @@ type GenericFlag struct {
 	Name        string
 	Value       Generic
 	Description string
+	Deprecated  string
 	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+	// Accept @path and - to read the value from a file or stdin
+	AllowFileValue bool
+	// The value is sensitive and is left out of crash reports
+	Secret bool
 }
 
 func (f GenericFlag) String() string {
-	return withEnvHint(f.EnvVar, fmt.Sprintf("-%s %v\t`%v` %s", f.Name, f.Value, "-"+f.Name+" option -"+f.Name+" option", f.Description))
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("-%s %v\t`%v` %s", f.Name, f.Value, "-"+f.Name+" option -"+f.Name+" option", f.Description))
 }
 
 func (f GenericFlag) Apply(set *flag.FlagSet) {
-	val := f.Value
-	if f.EnvVar != "" {
-		if envVal := os.Getenv(f.EnvVar); envVal != "" {
-			val.Set(envVal)
+	f.applyWithError(set)
+}
+
+func (f GenericFlag) applyWithError(set *flag.FlagSet) error {
+	if envVal, source, ok, err := envValue(f.EnvVar, f.EnvVars, f.FilePath); err != nil {
+		return envError(envVal, source, f.Name, err)
+	} else if ok {
+		if err := f.Value.Set(envVal); err != nil {
+			return envError(envVal, source, f.Name, err)
 		}
 	}
 
 	eachName(f.Name, func(name string) {
 		set.Var(f.Value, name, f.Description)
 	})
+	return nil
 }
 
 func (f GenericFlag) getName() string {
 	return f.Name
 }
 
+func (f GenericFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f GenericFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f GenericFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f GenericFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f GenericFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+func (f GenericFlag) allowsFileValue() bool {
+	return f.AllowFileValue
+}
+
+func (f GenericFlag) secret() bool {
+	return f.Secret
+}
+
+// BoolFlag is a flag that takes no argument. Value is the default; when
+// Negatable is set, every long name also gets a --no-<name> form that sets the
+// flag to false, so that a default of true can be turned off.
 type BoolFlag struct {
 	Name        string
+	Value       bool
+	Negatable   bool
 	Description string
+	Deprecated  string
 	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+	// Leave the flag out of help and completions
+	Hidden bool
 }
 
 func (f BoolFlag) String() string {
-	return withEnvHint(f.EnvVar, fmt.Sprintf("%s\t%v", prefixedNames(f.Name), f.Description))
+	names := prefixedNames(f.Name)
+	if f.Negatable {
+		names = negatableNames(f.Name)
+	}
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s\t%v", names, f.Description))
 }
 
 func (f BoolFlag) Apply(set *flag.FlagSet) {
-	val := false
-	if f.EnvVar != "" {
-		if envVal := os.Getenv(f.EnvVar); envVal != "" {
-			envValBool, err := strconv.ParseBool(envVal)
-			if err == nil {
-				val = envValBool
-			}
-		}
-	}
+	f.applyWithError(set)
+}
 
+func (f BoolFlag) applyWithError(set *flag.FlagSet) error {
+	// all forms of the flag share one value so that a later --no-<name> wins
+	val := boolValue(f.Value)
 	eachName(f.Name, func(name string) {
-		set.Bool(name, val, f.Description)
+		set.Var(&val, name, f.Description)
+		if f.Negatable && len(name) > 1 {
+			set.Var((*negatedBoolValue)(&val), "no-"+name, f.Description)
+		}
 	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
 }
 
 func (f BoolFlag) getName() string {
 	return f.Name
 }
 
+func (f BoolFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f BoolFlag) hidden() bool {
+	return f.Hidden
+}
+
+func (f BoolFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f BoolFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f BoolFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f BoolFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+// CountFlag is a flag that takes no argument and counts how often it is
+// given, such as -v -v -v for increasing verbosity. An explicit count can be
+// given with -v=3.
+type CountFlag struct {
+	Name        string
+	Value       int
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+}
+
+func (f CountFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s\t%v", prefixedNames(f.Name), f.Description))
+}
+
+func (f CountFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f CountFlag) applyWithError(set *flag.FlagSet) error {
+	val := countValue(f.Value)
+	eachName(f.Name, func(name string) {
+		set.Var(&val, name, f.Description)
+	})
+	// a count from the environment replaces the default rather than adding to it
+	if envVal, source, ok, err := envValue(f.EnvVar, f.EnvVars, f.FilePath); err != nil {
+		return envError(envVal, source, f.Name, err)
+	} else if ok {
+		n, err := parseCount(envVal)
+		if err != nil {
+			return envError(envVal, source, f.Name, err)
+		}
+		val = countValue(n)
+	}
+	return nil
+}
+
+func (f CountFlag) getName() string {
+	return f.Name
+}
+
+func (f CountFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f CountFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f CountFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+// BoolTFlag is a boolean flag that defaults to true.
+//
+// Deprecated: use a BoolFlag with Value and Negatable set, which can also be
+// turned off with --no-<name>.
 type BoolTFlag struct {
 	Name        string
 	Description string
+	Deprecated  string
 	EnvVar      string
+	EnvVars     []string
+	FilePath    string
 }
 
 func (f BoolTFlag) String() string {
-	return withEnvHint(f.EnvVar, fmt.Sprintf("%s\t%v", prefixedNames(f.Name), f.Description))
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s\t%v", prefixedNames(f.Name), f.Description))
 }
 
 func (f BoolTFlag) Apply(set *flag.FlagSet) {
-	val := true
-	if f.EnvVar != "" {
-		if envVal := os.Getenv(f.EnvVar); envVal != "" {
-			envValBool, err := strconv.ParseBool(envVal)
-			if err == nil {
-				val = envValBool
-			}
-		}
-	}
+	f.applyWithError(set)
+}
 
+func (f BoolTFlag) applyWithError(set *flag.FlagSet) error {
 	eachName(f.Name, func(name string) {
-		set.Bool(name, val, f.Description)
+		v := boolValue(true)
+		set.Var(&v, name, f.Description)
 	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
 }
 
 func (f BoolTFlag) getName() string {
 	return f.Name
 }
 
+func (f BoolTFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f BoolTFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f BoolTFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+// StringFlag is a flag for string values. With AllowFileValue set, a value of
+// @path is replaced by the contents of the file at path and a value of - by
+// the contents of stdin, which keeps secrets out of the process list.
 type StringFlag struct {
 	Name        string
 	Value       string
 	Description string
+	Deprecated  string
 	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+	// Accept @path and - to read the value from a file or stdin
+	AllowFileValue bool
+	// The value is sensitive and is left out of crash reports
+	Secret bool
+	// Leave the flag out of help and completions
+	Hidden bool
 }
 
 func (f StringFlag) String() string {
@@ -159,115 +465,865 @@ func (f StringFlag) String() string {
 		fmtString = "%s %v\t%v"
 	}
 
-	return withEnvHint(f.EnvVar, fmt.Sprintf(fmtString, prefixedNames(f.Name), f.Value, f.Description))
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf(fmtString, prefixedNames(f.Name), f.Value, f.Description))
 }
 
 func (f StringFlag) Apply(set *flag.FlagSet) {
-	if f.EnvVar != "" {
-		if envVal := os.Getenv(f.EnvVar); envVal != "" {
-			f.Value = envVal
-		}
-	}
+	f.applyWithError(set)
+}
 
+func (f StringFlag) applyWithError(set *flag.FlagSet) error {
 	eachName(f.Name, func(name string) {
 		set.String(name, f.Value, f.Description)
 	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
 }
 
 func (f StringFlag) getName() string {
 	return f.Name
 }
 
+func (f StringFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f StringFlag) hidden() bool {
+	return f.Hidden
+}
+
+func (f StringFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f StringFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f StringFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f StringFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+func (f StringFlag) allowsFileValue() bool {
+	return f.AllowFileValue
+}
+
+func (f StringFlag) secret() bool {
+	return f.Secret
+}
+
 type IntFlag struct {
 	Name        string
 	Value       int
 	Description string
+	Deprecated  string
 	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
 }
 
 func (f IntFlag) String() string {
-	return withEnvHint(f.EnvVar, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
 }
 
 func (f IntFlag) Apply(set *flag.FlagSet) {
-	if f.EnvVar != "" {
-		if envVal := os.Getenv(f.EnvVar); envVal != "" {
-			envValInt, err := strconv.ParseUint(envVal, 10, 64)
-			if err == nil {
-				f.Value = int(envValInt)
-			}
-		}
-	}
+	f.applyWithError(set)
+}
 
+func (f IntFlag) applyWithError(set *flag.FlagSet) error {
 	eachName(f.Name, func(name string) {
-		set.Int(name, f.Value, f.Description)
+		v := intValue(f.Value)
+		set.Var(&v, name, f.Description)
 	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
 }
 
 func (f IntFlag) getName() string {
 	return f.Name
 }
 
+func (f IntFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f IntFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f IntFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f IntFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f IntFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
 type DurationFlag struct {
 	Name        string
 	Value       time.Duration
 	Description string
+	Deprecated  string
 	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
 }
 
 func (f DurationFlag) String() string {
-	return withEnvHint(f.EnvVar, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
 }
 
 func (f DurationFlag) Apply(set *flag.FlagSet) {
-	if f.EnvVar != "" {
-		if envVal := os.Getenv(f.EnvVar); envVal != "" {
-			envValDuration, err := time.ParseDuration(envVal)
-			if err == nil {
-				f.Value = envValDuration
-			}
-		}
-	}
+	f.applyWithError(set)
+}
 
+func (f DurationFlag) applyWithError(set *flag.FlagSet) error {
 	eachName(f.Name, func(name string) {
-		set.Duration(name, f.Value, f.Description)
+		v := durationValue(f.Value)
+		set.Var(&v, name, f.Description)
 	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
 }
 
 func (f DurationFlag) getName() string {
 	return f.Name
 }
 
+func (f DurationFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f DurationFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f DurationFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f DurationFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f DurationFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
 type Float64Flag struct {
 	Name        string
 	Value       float64
 	Description string
+	Deprecated  string
 	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
 }
 
 func (f Float64Flag) String() string {
-	return withEnvHint(f.EnvVar, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
 }
 
 func (f Float64Flag) Apply(set *flag.FlagSet) {
-	if f.EnvVar != "" {
-		if envVal := os.Getenv(f.EnvVar); envVal != "" {
-			envValFloat, err := strconv.ParseFloat(envVal, 10)
-			if err == nil {
-				f.Value = float64(envValFloat)
-			}
-		}
-	}
+	f.applyWithError(set)
+}
 
+func (f Float64Flag) applyWithError(set *flag.FlagSet) error {
 	eachName(f.Name, func(name string) {
-		set.Float64(name, f.Value, f.Description)
+		v := float64Value(f.Value)
+		set.Var(&v, name, f.Description)
 	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
 }
 
 func (f Float64Flag) getName() string {
 	return f.Name
 }
 
+func (f Float64Flag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f Float64Flag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f Float64Flag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f Float64Flag) prompt() string {
+	return f.Prompt
+}
+
+func (f Float64Flag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+type Int64Flag struct {
+	Name        string
+	Value       int64
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f Int64Flag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
+}
+
+func (f Int64Flag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f Int64Flag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		v := int64Value(f.Value)
+		set.Var(&v, name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f Int64Flag) getName() string {
+	return f.Name
+}
+
+func (f Int64Flag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f Int64Flag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f Int64Flag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f Int64Flag) prompt() string {
+	return f.Prompt
+}
+
+func (f Int64Flag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+type UintFlag struct {
+	Name        string
+	Value       uint
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f UintFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
+}
+
+func (f UintFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f UintFlag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		v := uintValue(f.Value)
+		set.Var(&v, name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f UintFlag) getName() string {
+	return f.Name
+}
+
+func (f UintFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f UintFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f UintFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f UintFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f UintFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+type Uint64Flag struct {
+	Name        string
+	Value       uint64
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f Uint64Flag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
+}
+
+func (f Uint64Flag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f Uint64Flag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		v := uint64Value(f.Value)
+		set.Var(&v, name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f Uint64Flag) getName() string {
+	return f.Name
+}
+
+func (f Uint64Flag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f Uint64Flag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f Uint64Flag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f Uint64Flag) prompt() string {
+	return f.Prompt
+}
+
+func (f Uint64Flag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+// TimestampFlag is a flag for time.Time values. Layout is the reference
+// layout used to parse and print the value, defaulting to time.RFC3339.
+type TimestampFlag struct {
+	Name        string
+	Layout      string
+	Value       time.Time
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f TimestampFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s %s\t%v", prefixedNames(f.Name), quoteDefault(f.newValue(f.Value).String()), f.Description))
+}
+
+func (f TimestampFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f TimestampFlag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		set.Var(f.newValue(f.Value), name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f TimestampFlag) getName() string {
+	return f.Name
+}
+
+func (f TimestampFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f TimestampFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f TimestampFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f TimestampFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f TimestampFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+func (f TimestampFlag) newValue(t time.Time) *timestampValue {
+	layout := f.Layout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return &timestampValue{time: t, layout: layout}
+}
+
+// ByteSizeFlag is a flag for sizes given in bytes or with a unit suffix,
+// such as "512MiB" or "10KB". See ParseByteSize for the accepted units.
+type ByteSizeFlag struct {
+	Name        string
+	Value       ByteSize
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f ByteSizeFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s '%v'\t%v", prefixedNames(f.Name), f.Value, f.Description))
+}
+
+func (f ByteSizeFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f ByteSizeFlag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		v := f.Value
+		set.Var(&v, name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f ByteSizeFlag) getName() string {
+	return f.Name
+}
+
+func (f ByteSizeFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f ByteSizeFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f ByteSizeFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f ByteSizeFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f ByteSizeFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+// URLFlag is a flag for absolute URLs, such as "https://example.com/api".
+type URLFlag struct {
+	Name        string
+	Value       *url.URL
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f URLFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s %s\t%v", prefixedNames(f.Name), quoteDefault((&urlValue{f.Value}).String()), f.Description))
+}
+
+func (f URLFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f URLFlag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		set.Var(&urlValue{copyURL(f.Value)}, name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f URLFlag) getName() string {
+	return f.Name
+}
+
+func (f URLFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f URLFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f URLFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f URLFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f URLFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+// IPFlag is a flag for IPv4 or IPv6 addresses.
+type IPFlag struct {
+	Name        string
+	Value       net.IP
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f IPFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s %s\t%v", prefixedNames(f.Name), quoteDefault((&ipValue{f.Value}).String()), f.Description))
+}
+
+func (f IPFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f IPFlag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		set.Var(&ipValue{f.Value}, name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f IPFlag) getName() string {
+	return f.Name
+}
+
+func (f IPFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f IPFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f IPFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f IPFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f IPFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+// CIDRFlag is a flag for networks in CIDR notation, such as "10.0.0.0/8".
+type CIDRFlag struct {
+	Name        string
+	Value       *net.IPNet
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f CIDRFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s %s\t%v", prefixedNames(f.Name), quoteDefault((&cidrValue{f.Value}).String()), f.Description))
+}
+
+func (f CIDRFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f CIDRFlag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		set.Var(&cidrValue{f.Value}, name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f CIDRFlag) getName() string {
+	return f.Name
+}
+
+func (f CIDRFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f CIDRFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f CIDRFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f CIDRFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f CIDRFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+// RegexpFlag is a flag for regular expressions in RE2 syntax.
+type RegexpFlag struct {
+	Name        string
+	Value       *regexp.Regexp
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f RegexpFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s %s\t%v", prefixedNames(f.Name), quoteDefault((&regexpValue{f.Value}).String()), f.Description))
+}
+
+func (f RegexpFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f RegexpFlag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		set.Var(&regexpValue{f.Value}, name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f RegexpFlag) getName() string {
+	return f.Name
+}
+
+func (f RegexpFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f RegexpFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f RegexpFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f RegexpFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f RegexpFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+// ChoiceFlag is a flag whose value must be one of Allowed. When IgnoreCase is
+// set, values are matched case-insensitively and stored as spelled in Allowed.
+type ChoiceFlag struct {
+	Name        string
+	Value       string
+	Allowed     []string
+	IgnoreCase  bool
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+	// Ask for the value in a terminal when it is not given
+	Prompt string
+}
+
+func (f ChoiceFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s %s\t%v", prefixedNames(f.Name), quoteDefault(f.Value), withChoices(f.Allowed, f.Description)))
+}
+
+func (f ChoiceFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f ChoiceFlag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		v := f.newValue()
+		v.value = f.Value
+		set.Var(v, name, f.Description)
+	})
+	return setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath)
+}
+
+func (f ChoiceFlag) getName() string {
+	return f.Name
+}
+
+func (f ChoiceFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f ChoiceFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f ChoiceFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f ChoiceFlag) prompt() string {
+	return f.Prompt
+}
+
+func (f ChoiceFlag) hasEnvValue() bool {
+	_, _, ok, _ := envValue(f.EnvVar, f.EnvVars, f.FilePath)
+	return ok
+}
+
+func (f ChoiceFlag) completions() []string {
+	return f.Allowed
+}
+
+func (f ChoiceFlag) newValue() *choiceValue {
+	return &choiceValue{allowed: f.Allowed, ignoreCase: f.IgnoreCase}
+}
+
+// ChoiceSliceFlag is a flag that may be given several times, or with a
+// comma-separated list, where every value must be one of Allowed.
+type ChoiceSliceFlag struct {
+	Name        string
+	Value       []string
+	Allowed     []string
+	IgnoreCase  bool
+	Description string
+	Deprecated  string
+	EnvVar      string
+	EnvVars     []string
+	FilePath    string
+}
+
+func (f ChoiceSliceFlag) String() string {
+	return withEnvHint(f.EnvVar, f.EnvVars, f.FilePath, fmt.Sprintf("%s %s\t%v", prefixedNames(f.Name), quoteDefault(strings.Join(f.Value, ",")), withChoices(f.Allowed, f.Description)))
+}
+
+func (f ChoiceSliceFlag) Apply(set *flag.FlagSet) {
+	f.applyWithError(set)
+}
+
+func (f ChoiceSliceFlag) applyWithError(set *flag.FlagSet) error {
+	eachName(f.Name, func(name string) {
+		set.Var(f.newValue(f.Value), name, f.Description)
+	})
+	if err := setFromEnv(set, f.Name, f.EnvVar, f.EnvVars, f.FilePath); err != nil {
+		return err
+	}
+
+	// values from the environment are replaced, not added to, on the command line
+	eachName(f.Name, func(name string) {
+		set.Lookup(name).Value.(*choiceSliceValue).changed = false
+	})
+	return nil
+}
+
+func (f ChoiceSliceFlag) getName() string {
+	return f.Name
+}
+
+func (f ChoiceSliceFlag) deprecated() string {
+	return f.Deprecated
+}
+
+func (f ChoiceSliceFlag) withEnvVars(names ...string) Flag {
+	f.EnvVars = appendEnvVars(f.EnvVars, names)
+	return f
+}
+
+func (f ChoiceSliceFlag) withDescription(description string) Flag {
+	f.Description = description
+	return f
+}
+
+func (f ChoiceSliceFlag) completions() []string {
+	return f.Allowed
+}
+
+func (f ChoiceSliceFlag) newValue(values []string) *choiceSliceValue {
+	return &choiceSliceValue{
+		choice: choiceValue{allowed: f.Allowed, ignoreCase: f.IgnoreCase},
+		values: append([]string(nil), values...),
+	}
+}
+
 func prefixedNames(fullName string) (prefixed string) {
 	parts := strings.Split(fullName, ",")
 	for i, name := range parts {
@@ -280,10 +1336,50 @@ func prefixedNames(fullName string) (prefixed string) {
 	return
 }
 
-func withEnvHint(envVar, str string) string {
-	envText := ""
-	if envVar != "" {
-		envText = fmt.Sprintf(" [$%s]", envVar)
+func withEnvHint(envVar string, envVars []string, filePath, str string) string {
+	var sources []string
+	for _, name := range envVarNames(envVar, envVars) {
+		sources = append(sources, "$"+name)
+	}
+	if filePath != "" {
+		sources = append(sources, "file "+filePath)
+	}
+	if len(sources) == 0 {
+		return str
+	}
+	return str + " [" + strings.Join(sources, ", ") + "]"
+}
+
+// negatableNames renders the names of a negatable flag, such as "-[no-]color, -c".
+func negatableNames(fullName string) (prefixed string) {
+	parts := strings.Split(fullName, ",")
+	for i, name := range parts {
+		name = strings.Trim(name, " ")
+		if len(name) > 1 {
+			prefixed += "-[no-]" + name
+		} else {
+			prefixed += "-" + name
+		}
+		if i < len(parts)-1 {
+			prefixed += ", "
+		}
+	}
+	return
+}
+
+// quoteDefault renders a default value for help output, leaving it blank when unset.
+func quoteDefault(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "'" + value + "'"
+}
+
+// withChoices appends the allowed values of a choice flag to its description.
+func withChoices(allowed []string, description string) string {
+	choices := "(" + strings.Join(allowed, "|") + ")"
+	if description == "" {
+		return choices
 	}
-	return str + envText
+	return description + " " + choices
 }
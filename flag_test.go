@@ -2,10 +2,16 @@ package cli
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 var boolFlagTests = []struct {
@@ -423,3 +429,555 @@ func TestParseGenericFromEnv(t *testing.T) {
 	}
 	a.Run([]string{"run"})
 }
+
+var extendedFlagHelpTests = []struct {
+	flag     Flag
+	expected string
+}{
+	{Int64Flag{Name: "n", Value: -5}, "-n '-5'\t"},
+	{UintFlag{Name: "n", Value: 5}, "-n '5'\t"},
+	{Uint64Flag{Name: "n"}, "-n '0'\t"},
+	{TimestampFlag{Name: "since", Layout: "2006-01-02", Value: time.Date(2014, 3, 1, 0, 0, 0, 0, time.UTC)}, "-since '2014-03-01'\t"},
+	{TimestampFlag{Name: "since"}, "-since \t"},
+	{ByteSizeFlag{Name: "max", Value: 512 * MiB}, "-max '512MiB'\t"},
+	{URLFlag{Name: "api", Value: &url.URL{Scheme: "https", Host: "example.com"}}, "-api 'https://example.com'\t"},
+	{IPFlag{Name: "bind", Value: net.IPv4(127, 0, 0, 1)}, "-bind '127.0.0.1'\t"},
+	{CIDRFlag{Name: "allow"}, "-allow \t"},
+	{RegexpFlag{Name: "match", Value: regexp.MustCompile("^a+$")}, "-match '^a+$'\t"},
+}
+
+func TestExtendedFlagHelpOutput(t *testing.T) {
+
+	for _, test := range extendedFlagHelpTests {
+		output := test.flag.String()
+
+		if output != test.expected {
+			t.Errorf("%q does not match %q", output, test.expected)
+		}
+	}
+}
+
+func TestParseMultiExtended(t *testing.T) {
+	a := App{
+		Flags: []Flag{
+			Int64Flag{Name: "offset, o"},
+			UintFlag{Name: "workers, w"},
+			Uint64Flag{Name: "seed"},
+			TimestampFlag{Name: "since, s", Layout: "2006-01-02"},
+			ByteSizeFlag{Name: "max, m"},
+			URLFlag{Name: "api"},
+			IPFlag{Name: "bind"},
+			CIDRFlag{Name: "allow"},
+			RegexpFlag{Name: "match"},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.Int64("o"), int64(-1<<40))
+			expect(t, ctx.Uint("workers"), uint(8))
+			expect(t, ctx.Uint64("seed"), uint64(1<<63))
+			expect(t, ctx.Timestamp("since"), time.Date(2014, 3, 1, 0, 0, 0, 0, time.UTC))
+			expect(t, ctx.Timestamp("s"), time.Date(2014, 3, 1, 0, 0, 0, 0, time.UTC))
+			expect(t, ctx.ByteSize("max"), 3*GiB)
+			expect(t, ctx.URL("api").Host, "example.com")
+			expect(t, ctx.IP("bind").String(), "::1")
+			expect(t, ctx.CIDR("allow").String(), "10.0.0.0/8")
+			expect(t, ctx.Regexp("match").MatchString("aaa"), true)
+		},
+	}
+	err := a.Run([]string{"run", "-o", "-1099511627776", "-w", "8", "-seed", "9223372036854775808",
+		"-since", "2014-03-01", "-m", "3GiB", "-api", "https://example.com/v1", "-bind", "::1",
+		"-allow", "10.1.2.3/8", "-match", "^a+$"})
+	expect(t, err, nil)
+}
+
+func TestParseExtendedFromEnv(t *testing.T) {
+	os.Setenv("APP_MAX_SIZE", "10KB")
+	os.Setenv("APP_SINCE", "2014-03-01")
+	a := App{
+		Flags: []Flag{
+			ByteSizeFlag{Name: "max, m", EnvVar: "APP_MAX_SIZE"},
+			TimestampFlag{Name: "since", Layout: "2006-01-02", EnvVar: "APP_SINCE"},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.ByteSize("m"), 10*KB)
+			expect(t, ctx.Timestamp("since").Year(), 2014)
+		},
+	}
+	a.Run([]string{"run"})
+}
+
+var extendedFlagErrorTests = []struct {
+	flag     Flag
+	arg      string
+	expected string
+}{
+	{Int64Flag{Name: "n"}, "x", `invalid value "x" for flag -n: expected a 64-bit integer`},
+	{UintFlag{Name: "n"}, "-1", `invalid value "-1" for flag -n: expected a non-negative integer`},
+	{TimestampFlag{Name: "t", Layout: "2006-01-02"}, "yesterday", `invalid value "yesterday" for flag -t: expected a timestamp in the format "2006-01-02"`},
+	{ByteSizeFlag{Name: "s"}, "lots", `invalid value "lots" for flag -s: expected a size such as 1024, 10KB or 512MiB`},
+	{URLFlag{Name: "u"}, "example.com", `invalid value "example.com" for flag -u: expected an absolute URL such as https://example.com`},
+	{IPFlag{Name: "ip"}, "localhost", `invalid value "localhost" for flag -ip: expected an IPv4 or IPv6 address`},
+}
+
+func TestExtendedFlagParseErrors(t *testing.T) {
+	for _, test := range extendedFlagErrorTests {
+		a := App{
+			Flags:  []Flag{test.flag},
+			Action: func(ctx *Context) {},
+		}
+		err := a.Run([]string{"run", "-" + test.flag.getName(), test.arg})
+		if err == nil {
+			t.Errorf("expected an error for %q", test.arg)
+			continue
+		}
+		expect(t, err.Error(), test.expected)
+	}
+}
+
+func TestChoiceFlagHelpOutput(t *testing.T) {
+	flag := ChoiceFlag{Name: "output, o", Value: "json", Allowed: []string{"json", "yaml"}, Description: "output format"}
+	expect(t, flag.String(), "-output, -o 'json'\toutput format (json|yaml)")
+
+	slice := ChoiceSliceFlag{Name: "include", Value: []string{"a", "b"}, Allowed: []string{"a", "b", "c"}}
+	expect(t, slice.String(), "-include 'a,b'\t(a|b|c)")
+}
+
+func TestParseChoice(t *testing.T) {
+	a := App{
+		Flags: []Flag{
+			ChoiceFlag{Name: "output, o", Value: "table", Allowed: []string{"json", "YAML", "table"}, IgnoreCase: true},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.String("output"), "YAML")
+			expect(t, ctx.String("o"), "YAML")
+		},
+	}
+	err := a.Run([]string{"run", "-o", "yaml"})
+	expect(t, err, nil)
+}
+
+func TestParseChoiceInvalid(t *testing.T) {
+	a := App{
+		Flags: []Flag{
+			ChoiceFlag{Name: "output", Allowed: []string{"json", "yaml", "table"}},
+		},
+		Action: func(ctx *Context) {},
+	}
+	err := a.Run([]string{"run", "-output", "JSON"})
+	expect(t, err.Error(), `invalid value "JSON" for flag -output: expected one of json, yaml, table`)
+}
+
+func TestParseChoiceSlice(t *testing.T) {
+	a := App{
+		Flags: []Flag{
+			ChoiceSliceFlag{Name: "include, i", Value: []string{"a"}, Allowed: []string{"a", "b", "c"}},
+		},
+		Action: func(ctx *Context) {
+			expect(t, reflect.DeepEqual(ctx.ChoiceSlice("include"), []string{"b", "c", "a"}), true)
+			expect(t, reflect.DeepEqual(ctx.ChoiceSlice("i"), []string{"b", "c", "a"}), true)
+		},
+	}
+	err := a.Run([]string{"run", "-i", "b,c", "-i", "a"})
+	expect(t, err, nil)
+}
+
+func TestParseChoiceSliceFromEnv(t *testing.T) {
+	os.Setenv("APP_INCLUDE", "c,b")
+	a := App{
+		Flags: []Flag{
+			ChoiceSliceFlag{Name: "include", Value: []string{"a"}, Allowed: []string{"a", "b", "c"}, EnvVar: "APP_INCLUDE"},
+		},
+		Action: func(ctx *Context) {
+			expect(t, reflect.DeepEqual(ctx.ChoiceSlice("include"), []string{"c", "b"}), true)
+		},
+	}
+	a.Run([]string{"run"})
+}
+
+func TestNegatableBoolFlagHelpOutput(t *testing.T) {
+	flag := BoolFlag{Name: "color, c", Value: true, Negatable: true, Description: "colorize output"}
+	expect(t, flag.String(), "-[no-]color, -c\tcolorize output")
+}
+
+var negatableBoolTests = []struct {
+	args     []string
+	expected bool
+}{
+	{[]string{"run"}, true},
+	{[]string{"run", "--no-color"}, false},
+	{[]string{"run", "--no-color", "--color"}, true},
+	{[]string{"run", "-c=false"}, false},
+}
+
+func TestParseNegatableBool(t *testing.T) {
+	for _, test := range negatableBoolTests {
+		a := App{
+			Flags: []Flag{
+				BoolFlag{Name: "color, c", Value: true, Negatable: true},
+			},
+			Action: func(ctx *Context) {
+				if ctx.Bool("color") != test.expected || ctx.Bool("c") != test.expected {
+					t.Errorf("%v: expected color to be %v", test.args, test.expected)
+				}
+			},
+		}
+		err := a.Run(test.args)
+		expect(t, err, nil)
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	a := App{
+		Flags: []Flag{
+			CountFlag{Name: "verbose, v"},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.Count("verbose"), 3)
+			expect(t, ctx.Count("v"), 3)
+		},
+	}
+	err := a.Run([]string{"run", "-v", "-v", "-v"})
+	expect(t, err, nil)
+}
+
+func TestParseCountExplicit(t *testing.T) {
+	os.Setenv("APP_VERBOSITY", "1")
+	a := App{
+		Flags: []Flag{
+			CountFlag{Name: "verbose", EnvVar: "APP_VERBOSITY"},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.Count("verbose"), 6)
+		},
+	}
+	err := a.Run([]string{"run", "-verbose=5", "-verbose"})
+	expect(t, err, nil)
+}
+
+func TestStringFlagWithFilePathHelpOutput(t *testing.T) {
+	flag := StringFlag{Name: "token", EnvVar: "APP_TOKEN", FilePath: "/run/secrets/token"}
+	expect(t, flag.String(), "-token \t [$APP_TOKEN, file /run/secrets/token]")
+}
+
+func writeTempFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteString(content)
+	return f.Name()
+}
+
+func TestParseStringFromFilePath(t *testing.T) {
+	path := writeTempFile(t, "s3cr3t\n")
+	defer os.Remove(path)
+	os.Unsetenv("APP_TOKEN")
+
+	a := App{
+		Flags: []Flag{
+			StringFlag{Name: "token, t", EnvVar: "APP_TOKEN", FilePath: path},
+			IntFlag{Name: "retries", FilePath: path + ".missing", Value: 3},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.String("token"), "s3cr3t")
+			expect(t, ctx.String("t"), "s3cr3t")
+			expect(t, ctx.Int("retries"), 3)
+		},
+	}
+	a.Run([]string{"run"})
+}
+
+func TestParseStringFromEnvBeforeFilePath(t *testing.T) {
+	path := writeTempFile(t, "from-file")
+	defer os.Remove(path)
+	os.Setenv("APP_TOKEN", "from-env")
+	defer os.Unsetenv("APP_TOKEN")
+
+	a := App{
+		Flags: []Flag{
+			StringFlag{Name: "token", EnvVar: "APP_TOKEN", FilePath: path},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.String("token"), "from-env")
+		},
+	}
+	a.Run([]string{"run"})
+}
+
+func TestParseFileValue(t *testing.T) {
+	path := writeTempFile(t, "@s3cr3t\r\n")
+	defer os.Remove(path)
+
+	a := App{
+		Flags: []Flag{
+			StringFlag{Name: "token, t", AllowFileValue: true},
+			StringFlag{Name: "user", AllowFileValue: true},
+			StringFlag{Name: "plain"},
+			GenericFlag{Name: "pair", Value: &Parser{}, AllowFileValue: true},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.String("token"), "@s3cr3t")
+			expect(t, ctx.String("t"), "@s3cr3t")
+			expect(t, ctx.String("user"), "@admin")
+			expect(t, ctx.String("plain"), "@"+path)
+			if !reflect.DeepEqual(ctx.Generic("pair"), &Parser{"1", "2"}) {
+				t.Errorf("generic value not set")
+			}
+		},
+	}
+	err := a.Run([]string{"run", "-token", "@" + path, "-user", "@@admin", "-plain", "@" + path, "-pair", "1,2"})
+	expect(t, err, nil)
+}
+
+func TestParseFileValueMissingFile(t *testing.T) {
+	a := App{
+		Flags: []Flag{
+			StringFlag{Name: "token", AllowFileValue: true},
+		},
+		Action: func(ctx *Context) {},
+	}
+	err := a.Run([]string{"run", "-token", "@/nonexistent/token"})
+	if err == nil || !strings.HasPrefix(err.Error(), `invalid value "@/nonexistent/token" for flag -token: cannot read value from file`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestParseFileValueFromStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = oldStdin
+	}()
+	w.WriteString("{\"name\": \"payload\"}\n")
+	w.Close()
+
+	a := App{
+		Flags: []Flag{
+			StringFlag{Name: "payload", AllowFileValue: true},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.String("payload"), `{"name": "payload"}`)
+		},
+	}
+	err = a.Run([]string{"run", "-payload", "-"})
+	expect(t, err, nil)
+}
+
+func TestParseFileValueFromAppReader(t *testing.T) {
+	a := App{
+		Reader: strings.NewReader("s3cret\n"),
+		Flags: []Flag{
+			StringFlag{Name: "token", AllowFileValue: true},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.String("token"), "s3cret")
+		},
+	}
+	expect(t, a.Run([]string{"run", "-token", "-"}), nil)
+}
+
+func TestParseFromUnreadableFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	missing := filepath.Join(dir, "missing")
+	tests := []struct {
+		flag    Flag
+		invalid bool
+	}{
+		{StringFlag{Name: "token", FilePath: dir}, true},
+		{CountFlag{Name: "verbose", FilePath: dir}, true},
+		{StringFlag{Name: "token", FilePath: missing}, false},
+	}
+	for _, test := range tests {
+		a := App{Flags: []Flag{test.flag}, Action: func(ctx *Context) {}}
+		err := a.Run([]string{"run"})
+		if test.invalid != (err != nil) {
+			t.Errorf("%s: unexpected error %v", test.flag.getName(), err)
+		} else if err != nil && !strings.Contains(err.Error(), "from file "+dir+" for flag") {
+			t.Errorf("%s: unexpected error %v", test.flag.getName(), err)
+		}
+	}
+}
+
+func TestFlagWithEnvVarsHelpOutput(t *testing.T) {
+	flag := IntFlag{Name: "timeout", EnvVar: "APP_TIMEOUT", EnvVars: []string{"APP_TIMEOUT_SECONDS", "TIMEOUT"}}
+	expect(t, flag.String(), "-timeout '0'\t [$APP_TIMEOUT, $APP_TIMEOUT_SECONDS, $TIMEOUT]")
+}
+
+func TestParseFromEnvVarsInOrder(t *testing.T) {
+	os.Unsetenv("APP_NEW_NAME")
+	os.Setenv("APP_OLD_NAME", "old")
+	os.Setenv("APP_OLDER_NAME", "older")
+	defer os.Unsetenv("APP_OLD_NAME")
+	defer os.Unsetenv("APP_OLDER_NAME")
+
+	a := App{
+		Flags: []Flag{
+			StringFlag{Name: "name", EnvVars: []string{"APP_NEW_NAME", "APP_OLD_NAME", "APP_OLDER_NAME"}},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.String("name"), "old")
+		},
+	}
+	a.Run([]string{"run"})
+}
+
+func TestParseNegativeIntFromEnv(t *testing.T) {
+	os.Setenv("APP_OFFSET", "-10")
+	defer os.Unsetenv("APP_OFFSET")
+	a := App{
+		Flags: []Flag{
+			IntFlag{Name: "offset, o", EnvVar: "APP_OFFSET"},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.Int("offset"), -10)
+			expect(t, ctx.Int("o"), -10)
+		},
+	}
+	err := a.Run([]string{"run"})
+	expect(t, err, nil)
+}
+
+var envErrorTests = []struct {
+	flag     Flag
+	value    string
+	expected string
+}{
+	{IntFlag{Name: "count, c", EnvVar: "APP_BAD"}, "ten", `invalid value "ten" from environment variable APP_BAD for flag -count: expected an integer`},
+	{BoolFlag{Name: "debug", EnvVar: "APP_BAD"}, "yes", `invalid value "yes" from environment variable APP_BAD for flag -debug: expected true or false`},
+	{Float64Flag{Name: "ratio", EnvVars: []string{"APP_BAD"}}, "1/2", `invalid value "1/2" from environment variable APP_BAD for flag -ratio: expected a number`},
+	{DurationFlag{Name: "timeout", EnvVar: "APP_BAD"}, "10", `invalid value "10" from environment variable APP_BAD for flag -timeout: expected a duration such as 45s or 1h30m`},
+	{GenericFlag{Name: "pair", Value: &Parser{}, EnvVar: "APP_BAD"}, "1", `invalid value "1" from environment variable APP_BAD for flag -pair: invalid format`},
+	{ChoiceFlag{Name: "output", Allowed: []string{"json"}, EnvVar: "APP_BAD"}, "xml", `invalid value "xml" from environment variable APP_BAD for flag -output: expected one of json`},
+}
+
+func TestParseInvalidFromEnv(t *testing.T) {
+	defer os.Unsetenv("APP_BAD")
+	for _, test := range envErrorTests {
+		os.Setenv("APP_BAD", test.value)
+		actionRun := false
+		a := App{
+			Flags: []Flag{test.flag},
+			Action: func(ctx *Context) {
+				actionRun = true
+			},
+		}
+		err := a.Run([]string{"run"})
+		if err == nil {
+			t.Errorf("expected an error for %q", test.value)
+			continue
+		}
+		expect(t, err.Error(), test.expected)
+		expect(t, actionRun, false)
+	}
+}
+
+func TestParseInvalidFromFilePath(t *testing.T) {
+	path := writeTempFile(t, "many\n")
+	defer os.Remove(path)
+
+	a := App{
+		Commands: []Command{
+			{
+				Name:   "fetch",
+				Flags:  []Flag{UintFlag{Name: "retries", FilePath: path}},
+				Action: func(ctx *Context) {},
+			},
+		},
+	}
+	err := a.Run([]string{"run", "fetch"})
+	expect(t, err.Error(), `invalid value "many" from file `+path+` for flag -retries: expected a non-negative integer`)
+}
+
+func TestParseChoiceSliceFromEnvReplacedByArgs(t *testing.T) {
+	os.Setenv("APP_INCLUDE", "a,b")
+	defer os.Unsetenv("APP_INCLUDE")
+	a := App{
+		Flags: []Flag{
+			ChoiceSliceFlag{Name: "include", Allowed: []string{"a", "b", "c"}, EnvVar: "APP_INCLUDE"},
+		},
+		Action: func(ctx *Context) {
+			expect(t, reflect.DeepEqual(ctx.ChoiceSlice("include"), []string{"c"}), true)
+		},
+	}
+	a.Run([]string{"run", "-include", "c"})
+}
+
+func TestParseCountAlias(t *testing.T) {
+	for _, args := range [][]string{{"run", "-v"}, {"run", "--verbose"}} {
+		a := App{
+			Flags: []Flag{
+				CountFlag{Name: "verbose, v"},
+			},
+			Action: func(ctx *Context) {
+				expect(t, ctx.Count("verbose"), 1)
+				expect(t, ctx.Count("v"), 1)
+			},
+		}
+		expect(t, a.Run(args), nil)
+	}
+}
+
+func TestParseNegatableBoolIsSet(t *testing.T) {
+	a := App{
+		Flags: []Flag{
+			BoolFlag{Name: "color, c", Value: true, Negatable: true},
+			BoolFlag{Name: "debug", Negatable: true},
+		},
+		Action: func(ctx *Context) {
+			expect(t, ctx.IsSet("color"), true)
+			expect(t, ctx.IsSet("c"), true)
+			expect(t, ctx.IsSet("no-color"), true)
+			expect(t, ctx.IsSet("debug"), false)
+		},
+	}
+	expect(t, a.Run([]string{"run", "--no-color"}), nil)
+}
+
+var countEnvTests = []struct {
+	env      string
+	args     []string
+	expected int
+}{
+	{"2", []string{"run"}, 2},
+	{"0", []string{"run"}, 0},
+	{"true", []string{"run"}, 1},
+	{"1", []string{"run", "-v"}, 2},
+	{"false", []string{"run", "-v", "-v"}, 2},
+}
+
+func TestParseCountFromEnvWithDefault(t *testing.T) {
+	defer os.Unsetenv("APP_VERBOSITY")
+	for _, test := range countEnvTests {
+		os.Setenv("APP_VERBOSITY", test.env)
+		a := App{
+			Flags: []Flag{
+				CountFlag{Name: "verbose, v", Value: 3, EnvVar: "APP_VERBOSITY"},
+			},
+			Action: func(ctx *Context) {
+				if ctx.Count("verbose") != test.expected || ctx.Count("v") != test.expected {
+					t.Errorf("APP_VERBOSITY=%s %v: expected a count of %d, got %d", test.env, test.args, test.expected, ctx.Count("v"))
+				}
+			},
+		}
+		expect(t, a.Run(test.args), nil)
+	}
+}
+
+func TestParseCountFromEnvInvalid(t *testing.T) {
+	os.Setenv("APP_VERBOSITY", "lots")
+	defer os.Unsetenv("APP_VERBOSITY")
+	a := App{
+		Flags:  []Flag{CountFlag{Name: "verbose", EnvVar: "APP_VERBOSITY"}},
+		Action: func(ctx *Context) {},
+	}
+	expect(t, a.Run([]string{"run"}) != nil, true)
+}
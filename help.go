@@ -2,7 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sync"
+	"sync/atomic"
 	"text/tabwriter"
 	"text/template"
 )
@@ -21,7 +24,11 @@ COMMANDS:
 {{range .Commands}}{{ "   " }}{{.Name}}{{ "\t" }}{{.ShortDescription}}{{ "\n" }}{{end}}
    Use '{{.Exec}} help <command> [<subcommand>]' for more
    information about a command or subcommand.
-{{ if .Flags }}
+{{ with .Aliases }}
+ALIASES:
+{{range $name, $expansion := .}}{{ "   " }}{{$name}}{{ "\t" }}{{$expansion}}{{ "\n" }}{{end}}{{ end }}{{ if .ListPlugins }}{{ with .Plugins }}
+PLUGINS:
+{{range .}}{{ "   " }}{{.Name}}{{ "\t" }}{{.Path}}{{ "\n" }}{{end}}{{ end }}{{ end }}{{ if .Flags }}
 OPTIONS:
 {{range .Flags}}{{ "   " }}{{.}}{{ "\n" }}{{end}}{{ end }}
 `
@@ -36,7 +43,12 @@ USAGE:
    {{.Usage}}{{if .Description}}
 
 DESCRIPTION:
-   {{.Description}}{{end}}{{if .Subcommands}}
+   {{.Description}}{{end}}{{if .Examples}}
+
+EXAMPLES:{{range $i, $e := .Examples}}{{if $i}}
+{{end}}{{with .Description}}
+   {{.}}{{end}}
+      $ {{.Command}}{{end}}{{end}}{{if .Subcommands}}
 
 SUBCOMMANDS:
    {{range .Subcommands}}{{.Name}}{{ "\t" }}{{.ShortDescription}}
@@ -53,13 +65,22 @@ USAGE:
    {{.Usage}}{{if .Description}}
 
 DESCRIPTION:
-   {{.Description}}{{end}}
+   {{.Description}}{{end}}{{if .Examples}}
+
+EXAMPLES:{{range $i, $e := .Examples}}{{if $i}}
+{{end}}{{with .Description}}
+   {{.}}{{end}}
+      $ {{.Command}}{{end}}{{end}}
 `
 
 var helpCommand = Command{
 	Name:             "help",
 	ShortDescription: "Shows a list of commands or help for one command",
-	Action: func(c *Context) {
+}
+
+func init() {
+	// set here since the help depends on App.Command, which lists helpCommand
+	helpCommand.Action = func(c *Context) {
 		args := c.Args()
 		if args.Present() {
 			if len(args) == 2 {
@@ -70,49 +91,79 @@ var helpCommand = Command{
 		} else {
 			ShowAppHelp(c)
 		}
-	},
+	}
 }
 
-// Prints help for the App
+// Prints help for every App without its own App.HelpPrinter. Unless it is
+// replaced, help goes to the Writer of the App, styled for a terminal.
+//
+// Deprecated: replacing it is not safe while apps run; set App.HelpPrinter instead.
 var HelpPrinter = printHelp
 
-// Prints version for the App
+// helpMu serializes the calls of HelpPrinter by showHelp. During them
+// helpProbe is set, and printHelp only records in helpDefault that it was
+// called, so that showHelp prints the help itself.
+var (
+	helpMu      sync.Mutex
+	helpProbe   int32
+	helpDefault int32
+)
+
+// Prints version for every App without its own App.VersionPrinter.
+//
+// Deprecated: replacing it is not safe while apps run; set App.VersionPrinter instead.
 var VersionPrinter = printVersion
 
 func ShowAppHelp(c *Context) {
-	HelpPrinter(AppHelpTemplate, c.App)
+	// show the environment variables derived from App.EnvPrefix and hide
+	// whatever is deprecated
+	app := c.App.copy()
+	app.Description = app.translate("app.description", app.Description)
+	app.Flags = app.localizeFlags(app.envPrefixed(visibleFlags(app.flags())))
+	app.Commands = visibleCommands(app.commands())
+	for i, command := range app.Commands {
+		app.Commands[i] = app.localizeCommand(command)
+	}
+	// show the aliases of AliasFile as well
+	if aliases, err := app.aliases(); err == nil {
+		app.Aliases = aliases
+	}
+	c.showHelp(firstNonEmpty(c.App.AppHelpTemplate, AppHelpTemplate), app)
 }
 
 // Prints the list of subcommands as the default app completion method
 func DefaultAppComplete(c *Context) {
-	for _, command := range c.App.Commands {
-		fmt.Println(command.Name)
+	for _, command := range c.App.commands() {
+		fmt.Fprintln(c.App.writer(), command.Name)
 	}
 }
 
 // Prints help for the given command
-func ShowCommandHelp(c *Context, command string) {
-	for _, c := range c.App.Commands {
+func ShowCommandHelp(ctx *Context, command string) {
+	for _, c := range ctx.App.commands() {
 		if c.HasName(command) {
-			HelpPrinter(CommandHelpTemplate, c)
+			c.Subcommands = visibleSubcommands(c.Subcommands)
+			c = ctx.App.localizeCommand(c)
+			ctx.showHelp(firstNonEmpty(ctx.App.CommandHelpTemplate, CommandHelpTemplate), c)
 			return
 		}
 	}
 
-	if c.App.CommandNotFound != nil {
-		c.App.CommandNotFound(c, command)
+	if ctx.App.CommandNotFound != nil {
+		ctx.App.CommandNotFound(ctx, command)
 	} else {
-		fmt.Printf("No help topic for '%v'\n", command)
+		fmt.Fprintf(ctx.App.writer(), ctx.App.message("no-help-topic")+"\n", command)
 	}
 }
 
 // Prints help for the given subcommand
 func ShowSubcommandHelp(ctx *Context, command, subcommand string) {
-	for _, c := range ctx.App.Commands {
+	for _, c := range ctx.App.commands() {
 		if c.HasName(command) {
 			for _, s := range c.Subcommands {
 				if s.HasName(subcommand) {
-					HelpPrinter(SubcommandHelpTemplate, s)
+					s = ctx.App.localizeSubcommand(c.Name, s)
+					ctx.showHelp(firstNonEmpty(ctx.App.SubcommandHelpTemplate, SubcommandHelpTemplate), s)
 					return
 				}
 			}
@@ -122,27 +173,114 @@ func ShowSubcommandHelp(ctx *Context, command, subcommand string) {
 	if ctx.App.CommandNotFound != nil {
 		ctx.App.CommandNotFound(ctx, command)
 	} else {
-		fmt.Printf("No help topic for '%v'\n", command)
+		fmt.Fprintf(ctx.App.writer(), ctx.App.message("no-help-topic")+"\n", command)
 	}
 }
 
 // Prints the version number of the App
 func ShowVersion(c *Context) {
-	VersionPrinter(c)
+	if c.App.VersionPrinter != nil {
+		c.App.VersionPrinter(c)
+	} else {
+		VersionPrinter(c)
+	}
+}
+
+// showHelp prints help with the HelpPrinter of the App, the package
+// HelpPrinter if it was replaced, or else PrintHelp to the Writer of the App.
+// Help for a terminal is wrapped to its width and, with ColorHelp, colorized.
+// With PageHelp, help longer than the terminal is shown through a pager.
+func (c *Context) showHelp(templ string, data interface{}) {
+	a := c.App
+	templ = a.localizeTemplate(templ)
+	switch {
+	case a.HelpPrinter != nil:
+		a.HelpPrinter(a.writer(), templ, data)
+	case callHelpPrinter(templ, data):
+		if width, _, ok := a.terminalSize(); ok {
+			w := io.WriteCloser(nopCloser{a.writer()})
+			if a.PageHelp {
+				w = c.Pager()
+			}
+			printStyledHelp(w, templ, data, width, c.useColor())
+			w.Close()
+		} else {
+			PrintHelp(a.writer(), templ, data)
+		}
+	}
 }
 
-func printVersion(c *Context) {
-	fmt.Printf("%v version %v\n", c.App.Name, c.App.Version)
+// PrintHelp executes the help template templ with data and writes the result,
+// with its columns aligned, to w.
+// callHelpPrinter calls HelpPrinter and reports whether it is, or called,
+// printHelp, which then printed nothing.
+func callHelpPrinter(templ string, data interface{}) bool {
+	helpMu.Lock()
+	defer helpMu.Unlock()
+	atomic.StoreInt32(&helpDefault, 0)
+	atomic.StoreInt32(&helpProbe, 1)
+	defer atomic.StoreInt32(&helpProbe, 0)
+	HelpPrinter(templ, data)
+	return atomic.LoadInt32(&helpDefault) != 0
 }
 
 func printHelp(templ string, data interface{}) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	if atomic.LoadInt32(&helpProbe) != 0 {
+		atomic.StoreInt32(&helpDefault, 1)
+		return
+	}
+	PrintHelp(os.Stdout, templ, data)
+}
+
+func PrintHelp(w io.Writer, templ string, data interface{}) {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
 	t := template.Must(template.New("help").Parse(templ))
-	err := t.Execute(w, data)
+	err := t.Execute(tw, data)
 	if err != nil {
 		panic(err)
 	}
-	w.Flush()
+	tw.Flush()
+}
+
+func firstNonEmpty(s, fallback string) string {
+	if s != "" {
+		return s
+	}
+	return fallback
+}
+
+// visibleFlags returns the flags that are neither deprecated nor hidden.
+func visibleFlags(flags []Flag) (visible []Flag) {
+	for _, f := range flags {
+		if df, ok := f.(deprecatedFlag); ok && df.deprecated() != "" {
+			continue
+		}
+		if hf, ok := f.(hiddenFlag); ok && hf.hidden() {
+			continue
+		}
+		visible = append(visible, f)
+	}
+	return
+}
+
+// visibleCommands returns the commands that are not deprecated.
+func visibleCommands(commands []Command) (visible []Command) {
+	for _, c := range commands {
+		if c.Deprecated == "" {
+			visible = append(visible, c)
+		}
+	}
+	return
+}
+
+// visibleSubcommands returns the subcommands that are not deprecated.
+func visibleSubcommands(subcommands []Subcommand) (visible []Subcommand) {
+	for _, s := range subcommands {
+		if s.Deprecated == "" {
+			visible = append(visible, s)
+		}
+	}
+	return
 }
 
 func checkVersion(c *Context) bool {
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// A Catalog holds translated messages by locale and then by message ID, e.g.
+// catalog["de"]["incorrect-usage"]. Locales are a language, such as "de", or
+// a language and territory, such as "de_AT", which is preferred when it
+// matches.
+type Catalog map[string]map[string]string
+
+// defaultMessages are the built-in messages in English by message ID.
+var defaultMessages = map[string]string{
+	"incorrect-usage":    "Incorrect Usage - type '%s help' for info",
+	"no-help-topic":      "No help topic for '%v'",
+	"two-forms":          "Cannot use two forms of the same flag: %s %s",
+	"deprecated-warning": "Warning: %s is deprecated: %s",
+	"answer-yes-no":      "Please answer yes or no.",
+	"choose-number":      "Please choose a number from 1 to %d.",
+	"invalid-value":      "Invalid value: %v",
+	"unknown-command":    "Unknown command '%s' - type 'help' for a list of commands",
+	"nested-script":      "run-script cannot be run from a script",
+	"script-failures":    "%d of %d commands failed:",
+	"script-failure":     "   line %d: %s\n      %v",
+	"script-failed-at":   "script failed at line %d: %v",
+	"script-failed":      "script failed: %d commands failed",
+	"signal-stopping":    "Received %v, stopping (repeat to exit immediately)",
+	"signal-exiting":     "Received %v again, exiting",
+	"shutdown-timeout":   "Did not stop within %v, exiting",
+	"crashed":            "%s crashed unexpectedly: %v",
+	"crash-report":       "A crash report was saved to %s, please include it when reporting this problem.",
+	"crash-report-error": "The crash report could not be saved: %v",
+	"usage":              "USAGE",
+	"description":        "DESCRIPTION",
+	"commands":           "COMMANDS",
+	"subcommands":        "SUBCOMMANDS",
+	"examples":           "EXAMPLES",
+	"aliases":            "ALIASES",
+	"plugins":            "PLUGINS",
+	"options":            "OPTIONS",
+	"help-hint":          "Use '{{.Exec}} help <command> [<subcommand>]' for more\n   information about a command or subcommand.",
+}
+
+// helpHeadings are the message IDs of the headings of the help templates.
+var helpHeadings = []string{"usage", "description", "commands", "subcommands", "examples", "aliases", "plugins", "options"}
+
+// Message returns the message id in the locale of the App, from App.Catalog,
+// or else the built-in message, formatted like fmt.Sprintf when args are
+// given. An unknown message is id itself.
+func (c *Context) Message(id string, args ...interface{}) string {
+	text := c.App.message(id)
+	if text == "" {
+		text = id
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// locale returns Locale, or else the locale set by LC_ALL, LC_MESSAGES or LANG.
+func (a *App) locale() string {
+	if a.Locale != "" {
+		return a.Locale
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// translate returns the message id from Catalog in the locale of the App, or
+// fallback if it has none.
+func (a *App) translate(id, fallback string) string {
+	if len(a.Catalog) == 0 {
+		return fallback
+	}
+	for _, locale := range localeCandidates(a.locale()) {
+		if text, ok := a.Catalog[locale][id]; ok {
+			return text
+		}
+	}
+	return fallback
+}
+
+// message returns the built-in message id in the locale of the App.
+func (a *App) message(id string) string {
+	return a.translate(id, defaultMessages[id])
+}
+
+// localeCandidates returns the catalog locales to look in for a locale such as
+// "de_AT.UTF-8": "de_AT", then "de". The C and POSIX locales have none.
+func localeCandidates(locale string) []string {
+	locale = strings.Replace(locale, "-", "_", -1)
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return nil
+	}
+	candidates := []string{locale}
+	if i := strings.Index(locale, "_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	return candidates
+}
+
+// localizeTemplate translates the headings and the help hint of the help
+// template templ, wherever it has them in English.
+func (a *App) localizeTemplate(templ string) string {
+	if len(a.Catalog) == 0 {
+		return templ
+	}
+	var pairs []string
+	for _, id := range helpHeadings {
+		pairs = append(pairs, "\n"+defaultMessages[id]+":", "\n"+a.message(id)+":")
+	}
+	pairs = append(pairs, defaultMessages["help-hint"], a.message("help-hint"))
+	return strings.NewReplacer(pairs...).Replace(templ)
+}
+
+// localizeCommand returns a copy of c with the descriptions of it and its
+// subcommands translated. Their message IDs are command.<name>.short and
+// command.<name>.description, e.g. command.remote.add.short.
+func (a *App) localizeCommand(c Command) Command {
+	c.ShortDescription = a.translate("command."+c.Name+".short", c.ShortDescription)
+	c.Description = a.translate("command."+c.Name+".description", c.Description)
+	subcommands := make([]Subcommand, len(c.Subcommands))
+	for i, s := range c.Subcommands {
+		subcommands[i] = a.localizeSubcommand(c.Name, s)
+	}
+	c.Subcommands = subcommands
+	return c
+}
+
+// localizeSubcommand returns a copy of s, a subcommand of command, with its
+// descriptions translated.
+func (a *App) localizeSubcommand(command string, s Subcommand) Subcommand {
+	s.ShortDescription = a.translate("command."+command+"."+s.Name+".short", s.ShortDescription)
+	s.Description = a.translate("command."+command+"."+s.Name+".description", s.Description)
+	return s
+}
+
+// localizeFlags returns copies of flags with their descriptions translated.
+// The message ID of a flag is flag.<name>, with the path of its command in
+// between for flags of commands, e.g. flag.remote.add.protocol.
+func (a *App) localizeFlags(flags []Flag, path ...string) []Flag {
+	if len(a.Catalog) == 0 {
+		return flags
+	}
+	localized := make([]Flag, len(flags))
+	for i, f := range flags {
+		if df, ok := f.(describedFlag); ok {
+			name := strings.Trim(strings.Split(f.getName(), ",")[0], " ")
+			id := strings.Join(append(append([]string{"flag"}, path...), name), ".")
+			if text := a.translate(id, ""); text != "" {
+				f = df.withDescription(text)
+			}
+		}
+		localized[i] = f
+	}
+	return localized
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var germanCatalog = Catalog{
+	"de": {
+		"incorrect-usage":          "Falsche Verwendung - '%s help' zeigt die Hilfe",
+		"usage":                    "VERWENDUNG",
+		"commands":                 "BEFEHLE",
+		"options":                  "OPTIONEN",
+		"help-hint":                "'{{.Exec}} help <Befehl>' zeigt mehr.",
+		"command.remote.short":     "Verwaltet entfernte Repositorys",
+		"command.remote.add.short": "Fügt ein entferntes Repository hinzu",
+		"flag.verbose":             "mehr ausgeben",
+	},
+	"de_AT": {
+		"commands": "KOMMANDOS",
+	},
+}
+
+func TestLocaleCandidates(t *testing.T) {
+	expect(t, reflect.DeepEqual(localeCandidates("de_AT.UTF-8@euro"), []string{"de_AT", "de"}), true)
+	expect(t, reflect.DeepEqual(localeCandidates("pt-BR"), []string{"pt_BR", "pt"}), true)
+	expect(t, reflect.DeepEqual(localeCandidates("fr"), []string{"fr"}), true)
+	expect(t, len(localeCandidates("C.UTF-8")), 0)
+	expect(t, len(localeCandidates("")), 0)
+}
+
+func TestApp_LocaleFromEnvironment(t *testing.T) {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if old, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, old)
+		} else {
+			defer os.Unsetenv(name)
+		}
+		os.Unsetenv(name)
+	}
+	app := &App{Catalog: germanCatalog}
+	os.Setenv("LANG", "de_AT.UTF-8")
+	expect(t, app.message("commands"), "KOMMANDOS")
+	expect(t, app.message("usage"), "VERWENDUNG")
+
+	os.Setenv("LC_ALL", "en_US.UTF-8")
+	expect(t, app.message("commands"), "COMMANDS")
+
+	app.Locale = "de"
+	expect(t, app.message("commands"), "BEFEHLE")
+}
+
+var localizedHelpTests = []struct {
+	args     []string
+	expected []string
+}{
+	{[]string{"help"}, []string{"VERWENDUNG:", "BEFEHLE:", "OPTIONEN:", "'git help <Befehl>' zeigt mehr.", "Verwaltet entfernte Repositorys", "mehr ausgeben"}},
+	{[]string{"help", "remote"}, []string{"remote - Verwaltet entfernte Repositorys", "Fügt ein entferntes Repository hinzu"}},
+	{[]string{"--nope"}, []string{"Falsche Verwendung - 'git help' zeigt die Hilfe"}},
+}
+
+func TestApp_LocalizedHelp(t *testing.T) {
+	for _, test := range localizedHelpTests {
+		var out bytes.Buffer
+		app := NewApp()
+		app.Name = "git"
+		app.Exec = "git"
+		app.Writer = &out
+		app.Catalog = germanCatalog
+		app.Locale = "de_DE.UTF-8"
+		app.Flags = []Flag{BoolFlag{Name: "verbose", Description: "say more"}}
+		app.Commands = []Command{
+			{
+				Name:             "remote",
+				ShortDescription: "Manages remotes",
+				Subcommands:      []Subcommand{{Name: "add", ShortDescription: "Adds a remote"}},
+				Action:           func(c *Context) {},
+			},
+		}
+
+		app.Run(append([]string{"git"}, test.args...))
+		for _, expected := range test.expected {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("%v: expected output to contain %q, got %q", test.args, expected, out.String())
+			}
+		}
+	}
+}
+
+func TestContext_Message(t *testing.T) {
+	app := &App{Locale: "de", Catalog: Catalog{"de": {"greeting": "Hallo %s"}}}
+	c := NewContext(app, flag.NewFlagSet("test", 0), nil)
+	expect(t, c.Message("greeting", "Jo"), "Hallo Jo")
+	expect(t, c.Message("answer-yes-no"), "Please answer yes or no.")
+	expect(t, c.Message("unknown"), "unknown")
+}
+
+func TestApp_LocalizedRuntimeMessages(t *testing.T) {
+	var errOut bytes.Buffer
+	app := NewApp()
+	app.Name = "ops"
+	app.ErrWriter = &errOut
+	app.EnableScripts = true
+	app.RecoverPanics = true
+	app.Locale = "de"
+	app.Catalog = Catalog{"de": {
+		"unknown-command":  "Unbekannter Befehl '%s'",
+		"script-failures":  "%d von %d Befehlen fehlgeschlagen:",
+		"script-failed-at": "Skript in Zeile %d fehlgeschlagen: %v",
+		"crashed":          "%s ist abgestürzt: %v",
+	}}
+	app.Commands = []Command{
+		{Name: "boom", Action: func(c *Context) { panic("kaputt") }},
+	}
+
+	path := writeTempFile(t, "bogus\n")
+	defer os.Remove(path)
+	err := app.Run([]string{"ops", "run-script", path})
+	expect(t, err.Error(), "Skript in Zeile 1 fehlgeschlagen: Unbekannter Befehl 'bogus'")
+	expect(t, errOut.String(), "1 von 1 Befehlen fehlgeschlagen:\n   line 1: bogus\n      Unbekannter Befehl 'bogus'\n")
+
+	err = app.Run([]string{"ops", "boom"})
+	expect(t, err.Error(), "ops ist abgestürzt: kaputt")
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// These flags configure the logger of Context.Logger. App.EnableLogging adds
+// them to the global flags, except those whose names a flag of the App has.
+var (
+	VerboseFlag = BoolFlag{
+		Name:        "verbose",
+		Description: "log debug messages",
+	}
+	QuietFlag = BoolFlag{
+		Name:        "quiet",
+		Description: "log errors only",
+	}
+	LogLevelFlag = ChoiceFlag{
+		Name:        "log-level",
+		Value:       "info",
+		Allowed:     []string{"debug", "info", "warn", "error"},
+		IgnoreCase:  true,
+		Description: "the lowest level of messages to log",
+	}
+	LogFormatFlag = ChoiceFlag{
+		Name:        "log-format",
+		Value:       "text",
+		Allowed:     []string{"text", "json"},
+		Description: "log as text or as JSON",
+	}
+	LogFileFlag = StringFlag{
+		Name:        "log-file",
+		Description: "append log messages to a file instead of stderr",
+	}
+)
+
+// Logger returns the logger configured by the logging flags, with the path of
+// the running command as the attribute "command". Unless App.EnableLogging is
+// set it is based on slog.Default.
+func (c *Context) Logger() *slog.Logger {
+	logger := c.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if c.commandPath != "" {
+		logger = logger.With("command", c.commandPath)
+	}
+	return logger
+}
+
+// loggingFlags returns the logging flags.
+func loggingFlags() []Flag {
+	return []Flag{VerboseFlag, QuietFlag, LogLevelFlag, LogFormatFlag, LogFileFlag}
+}
+
+// newLogger returns a logger configured by the logging flags of c, and a
+// function that closes the log file.
+func (a *App) newLogger(c *Context) (*slog.Logger, func() error, error) {
+	// an App flag named like a logging flag is used instead, and may have no
+	// default level
+	var level slog.Level
+	if name := c.GlobalString(LogLevelFlag.Name); name != "" {
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return nil, nil, err
+		}
+	}
+	switch {
+	case c.GlobalBool(QuietFlag.Name):
+		level = slog.LevelError
+	case c.GlobalBool(VerboseFlag.Name):
+		level = slog.LevelDebug
+	}
+
+	var w io.Writer = a.errWriter()
+	closeLog := func() error { return nil }
+	if path := c.GlobalString(LogFileFlag.Name); path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, closeLog = f, f.Close
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if c.GlobalString(LogFormatFlag.Name) == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts)), closeLog, nil
+	}
+	return slog.New(slog.NewTextHandler(w, opts)), closeLog, nil
+}
+
+// logRun logs that the command of c starts, and returns a function that logs
+// that it finished with err.
+func (c *Context) logRun() func(err error) {
+	logger := c.Logger()
+	logger.Debug("command started", "args", []string(c.Args()))
+	start := time.Now()
+	return func(err error) {
+		if err != nil {
+			logger.Debug("command finished", "duration", time.Since(start), "error", err)
+		} else {
+			logger.Debug("command finished", "duration", time.Since(start))
+		}
+	}
+}
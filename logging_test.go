@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApp_LoggingFlags(t *testing.T) {
+	app := NewApp()
+	app.EnableLogging = true
+	names := []string{}
+	for _, f := range app.flags() {
+		names = append(names, f.getName())
+	}
+	expect(t, strings.Join(names, " "), "version verbose quiet log-level log-format log-file")
+
+	app.EnableLogging = false
+	expect(t, len(app.flags()), 1)
+}
+
+func TestApp_LoggingFlagsOverlap(t *testing.T) {
+	verbose := false
+	app := NewApp()
+	app.Writer = ioutil.Discard
+	app.EnableLogging = true
+	app.Flags = []Flag{
+		BoolFlag{Name: "verbose, V"},
+		StringFlag{Name: "level, log-level"},
+	}
+	app.Action = func(c *Context) {
+		verbose = c.Bool("V")
+	}
+
+	names := []string{}
+	for _, f := range app.flags() {
+		names = append(names, f.getName())
+	}
+	expect(t, strings.Join(names, " "), "verbose, V level, log-level version quiet log-format log-file")
+	expect(t, app.Run([]string{"ops", "--verbose"}), nil)
+	expect(t, verbose, true)
+}
+
+func TestContext_Logger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ops.log")
+
+	var errOut bytes.Buffer
+	app := NewApp()
+	app.Writer = ioutil.Discard
+	app.ErrWriter = &errOut
+	app.EnableLogging = true
+	app.Commands = []Command{
+		{
+			Name: "deploy",
+			Action: func(c *Context) {
+				c.Logger().Info("deploying", "service", c.Args().First())
+				c.Logger().Debug("details")
+			},
+			Subcommands: []Subcommand{
+				{
+					Name: "rollback",
+					Action: func(c *Context) {
+						c.Logger().Warn("rolling back")
+					},
+				},
+			},
+		},
+	}
+
+	// text at info level
+	expect(t, app.Run([]string{"ops", "deploy", "api"}), nil)
+	lines := strings.Split(strings.TrimSpace(errOut.String()), "\n")
+	expect(t, len(lines), 1)
+	expect(t, strings.Contains(lines[0], `level=INFO msg=deploying command=deploy service=api`), true)
+
+	// --quiet wins over --log-level
+	errOut.Reset()
+	expect(t, app.Run([]string{"ops", "--quiet", "--log-level", "debug", "deploy", "rollback"}), nil)
+	expect(t, errOut.String(), "")
+
+	// JSON at debug level
+	errOut.Reset()
+	expect(t, app.Run([]string{"ops", "--verbose", "--log-format", "json", "deploy", "rollback"}), nil)
+	var messages, commands []string
+	for _, line := range strings.Split(strings.TrimSpace(errOut.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON log line %q: %v", line, err)
+		}
+		messages = append(messages, record["msg"].(string))
+		commands = append(commands, record["command"].(string))
+	}
+	expect(t, strings.Join(messages, ","), "command started,rolling back,command finished")
+	expect(t, strings.Join(commands, ","), "deploy rollback,deploy rollback,deploy rollback")
+
+	// a log file is appended to
+	errOut.Reset()
+	expect(t, app.Run([]string{"ops", "--log-file", path, "--log-level", "WARN", "deploy", "api"}), nil)
+	expect(t, app.Run([]string{"ops", "--log-file", path, "deploy", "rollback"}), nil)
+	expect(t, errOut.String(), "")
+	data, err := ioutil.ReadFile(path)
+	expect(t, err, nil)
+	lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	expect(t, len(lines), 1)
+	expect(t, strings.Contains(lines[0], `msg="rolling back" command="deploy rollback"`), true)
+}
+
+func TestContext_LoggerDefault(t *testing.T) {
+	c := NewContext(&App{}, nil, nil)
+	expect(t, c.Logger() != nil, true)
+}
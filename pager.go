@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// This flag turns off the pager. App.PageHelp adds it to the global flags.
+var NoPagerFlag = BoolFlag{
+	Name:        "no-pager",
+	Description: "do not pipe output through a pager",
+}
+
+// defaultPager is run when the PAGER environment variable is not set. The
+// options make less quit if the output fits on one screen, pass colors
+// through and leave the output on the screen.
+const defaultPager = "less -FRX"
+
+// Pager returns a writer whose output goes through $PAGER, or else less -FRX,
+// if the Writer of the App is a terminal and the output is longer than the
+// terminal. Otherwise, or if NoPagerFlag is given or the pager cannot be run,
+// it goes straight to the Writer of the App. The writer must be closed, which
+// waits for the pager to exit.
+func (c *Context) Pager() io.WriteCloser {
+	width, height, ok := c.App.terminalSize()
+	if !ok || c.noPager() {
+		return nopCloser{c.App.writer()}
+	}
+	return newPager(c.App.writer(), c.App.errWriter(), width, height)
+}
+
+func (c *Context) noPager() bool {
+	return lookupBool(NoPagerFlag.Name, c.flagSet) || lookupBool(NoPagerFlag.Name, c.globalSet)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// pager holds back output until it is longer than height rows, then starts
+// the pager and copies everything to it.
+type pager struct {
+	out, errOut   io.Writer
+	width, height int
+	buf           bytes.Buffer
+	cmd           *exec.Cmd
+	stdin         io.WriteCloser
+	// direct is set once output goes straight to out
+	direct bool
+	// quit is set once the pager stops reading, after which output is dropped
+	quit bool
+}
+
+func newPager(out, errOut io.Writer, width, height int) *pager {
+	return &pager{out: out, errOut: errOut, width: width, height: height}
+}
+
+func (p *pager) Write(b []byte) (int, error) {
+	switch {
+	case p.quit:
+		return len(b), nil
+	case p.stdin != nil:
+		if _, err := p.stdin.Write(b); err != nil {
+			// the pager was closed before reading everything
+			p.quit = true
+		}
+		return len(b), nil
+	case p.direct:
+		return p.out.Write(b)
+	}
+
+	p.buf.Write(b)
+	// leave a row for the prompt of the pager
+	if rows(p.buf.String(), p.width) < p.height {
+		return len(b), nil
+	}
+	if err := p.start(); err != nil {
+		p.direct = true
+	}
+	data := p.buf.Bytes()
+	p.buf.Reset()
+	if _, err := p.Write(data); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close writes output that was held back and waits for the pager to exit.
+func (p *pager) Close() error {
+	if p.stdin == nil {
+		_, err := p.out.Write(p.buf.Bytes())
+		p.buf.Reset()
+		return err
+	}
+	p.stdin.Close()
+	err := p.cmd.Wait()
+	p.stdin = nil
+	if p.quit {
+		return nil
+	}
+	return err
+}
+
+// start runs $PAGER, or else defaultPager, with its output going to out.
+func (p *pager) start() error {
+	command := os.Getenv("PAGER")
+	if command == "" {
+		command = defaultPager
+	}
+	args, err := tokenize(command)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return exec.ErrNotFound
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = p.out
+	cmd.Stderr = p.errOut
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.cmd, p.stdin = cmd, stdin
+	return nil
+}
+
+// rows returns the number of terminal rows text takes up when it is width
+// columns wide.
+func rows(text string, width int) int {
+	n := 0
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		n += (textWidth(line) + width - 1) / width
+		if line == "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,117 @@
+//go:build !windows
+
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func withPager(command string) func() {
+	old, ok := os.LookupEnv("PAGER")
+	os.Setenv("PAGER", command)
+	return func() {
+		if ok {
+			os.Setenv("PAGER", old)
+		} else {
+			os.Unsetenv("PAGER")
+		}
+	}
+}
+
+func TestPager_Short(t *testing.T) {
+	defer withPager("tr a-z A-Z")()
+	var out bytes.Buffer
+	p := newPager(&out, &out, 80, 5)
+	fmt.Fprintln(p, "one")
+	fmt.Fprintln(p, "two")
+	expect(t, out.String(), "")
+	expect(t, p.Close(), nil)
+	expect(t, out.String(), "one\ntwo\n")
+}
+
+func TestPager_Long(t *testing.T) {
+	defer withPager("tr a-z A-Z")()
+	var out bytes.Buffer
+	p := newPager(&out, &out, 80, 3)
+	for _, line := range []string{"one", "two", "three", "four"} {
+		fmt.Fprintln(p, line)
+	}
+	expect(t, p.Close(), nil)
+	expect(t, out.String(), "ONE\nTWO\nTHREE\nFOUR\n")
+}
+
+func TestPager_WrappedLines(t *testing.T) {
+	defer withPager("tr a-z A-Z")()
+	var out bytes.Buffer
+	p := newPager(&out, &out, 10, 3)
+	fmt.Fprintln(p, strings.Repeat("x", 25))
+	expect(t, p.Close(), nil)
+	expect(t, out.String(), strings.Repeat("X", 25)+"\n")
+}
+
+func TestPager_NotFound(t *testing.T) {
+	defer withPager("no-such-pager-command")()
+	var out bytes.Buffer
+	p := newPager(&out, &out, 80, 2)
+	fmt.Fprintln(p, "one")
+	fmt.Fprintln(p, "two")
+	fmt.Fprintln(p, "three")
+	expect(t, p.Close(), nil)
+	expect(t, out.String(), "one\ntwo\nthree\n")
+}
+
+func TestPager_Quit(t *testing.T) {
+	defer withPager("head -n 1")()
+	var out bytes.Buffer
+	p := newPager(&out, &out, 80, 2)
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintln(p, i)
+	}
+	expect(t, p.Close(), nil)
+	expect(t, out.String(), "0\n")
+}
+
+func TestContext_PagerNotTerminal(t *testing.T) {
+	var out bytes.Buffer
+	set := flag.NewFlagSet("test", 0)
+	c := NewContext(&App{Writer: &out}, set, set)
+	w := c.Pager()
+	fmt.Fprintln(w, "hello")
+	expect(t, w.Close(), nil)
+	expect(t, out.String(), "hello\n")
+}
+
+func TestApp_PageHelpAddsNoPagerFlag(t *testing.T) {
+	app := NewApp()
+	app.Writer = ioutil.Discard
+	app.PageHelp = true
+	noPager := false
+	app.Action = func(c *Context) {
+		noPager = c.noPager()
+	}
+	expect(t, app.Run([]string{"app", "--no-pager"}), nil)
+	expect(t, noPager, true)
+
+	app.PageHelp = false
+	expect(t, len(app.flags()), 1)
+}
+
+func TestApp_NoPagerFlagOverlap(t *testing.T) {
+	app := NewApp()
+	app.Writer = ioutil.Discard
+	app.PageHelp = true
+	app.Flags = []Flag{BoolFlag{Name: "no-pager, P"}}
+	noPager := false
+	app.Action = func(c *Context) {
+		noPager = c.noPager()
+	}
+	expect(t, len(app.flags()), 2)
+	expect(t, app.Run([]string{"app", "-P"}), nil)
+	expect(t, noPager, true)
+}
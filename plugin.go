@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Plugin is an executable named <app>-<command> that runs an unknown command.
+type Plugin struct {
+	// The command the plugin provides
+	Name string
+	// The path of the executable
+	Path string
+}
+
+// PluginExitError is returned by Run when a plugin or a shell alias exits with
+// a non-zero status.
+type PluginExitError struct {
+	Plugin string
+	Code   int
+	// Set if Plugin is the name of a shell alias
+	Alias bool
+}
+
+func (e *PluginExitError) Error() string {
+	if e.Alias {
+		return fmt.Sprintf("alias '%s' exited with status %d", e.Plugin, e.Code)
+	}
+	return fmt.Sprintf("plugin '%s' exited with status %d", e.Plugin, e.Code)
+}
+
+// ExitCode returns the exit status of the plugin or alias.
+func (e *PluginExitError) ExitCode() int {
+	return e.Code
+}
+
+// Plugins returns the plugins found in PluginDirs and on PATH, sorted by name.
+// If two directories hold the same plugin, the first one wins. Plugins that
+// would replace a command of the App are left out.
+func (a *App) Plugins() []Plugin {
+	if !a.EnablePlugins {
+		return nil
+	}
+
+	prefix := filepath.Base(a.Name) + "-"
+	dirs := append(append([]string{}, a.PluginDirs...), filepath.SplitList(os.Getenv("PATH"))...)
+	seen := make(map[string]bool)
+	var plugins []Plugin
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, info := range files {
+			if info.Mode()&os.ModeSymlink != 0 {
+				if target, err := os.Stat(filepath.Join(dir, info.Name())); err == nil {
+					info = target
+				}
+			}
+			name, ok := executableName(info)
+			if !ok || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+				continue
+			}
+			name = name[len(prefix):]
+			if seen[name] || a.Command(name) != nil {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: filepath.Join(dir, info.Name())})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins
+}
+
+// plugin returns the named plugin, found in PluginDirs or on PATH, or nil if
+// there is none.
+func (a *App) plugin(name string) *Plugin {
+	if !a.EnablePlugins || name == "" || strings.ContainsAny(name, `/\`) || a.Command(name) != nil {
+		return nil
+	}
+	file := filepath.Base(a.Name) + "-" + name
+	for _, dir := range a.PluginDirs {
+		if dir == "" {
+			continue
+		}
+		if path, err := exec.LookPath(filepath.Join(dir, file)); err == nil {
+			return &Plugin{Name: name, Path: path}
+		}
+	}
+	if path, err := exec.LookPath(file); err == nil {
+		return &Plugin{Name: name, Path: path}
+	}
+	return nil
+}
+
+// runPlugin runs p with args, connected to the standard streams of the App.
+// The values of the global flags in PluginFlags are exported to it as
+// environment variables.
+func (a *App) runPlugin(c *Context, p *Plugin, args []string) error {
+	cmd := exec.Command(p.Path, args...)
+	cmd.Env = append(os.Environ(), a.pluginEnv(c)...)
+	return a.runExternal(&PluginExitError{Plugin: p.Name}, cmd)
+}
+
+// runExternal runs cmd, connected to the standard streams of the App. If it
+// exits with a non-zero status, exit is returned with the status set.
+func (a *App) runExternal(exit *PluginExitError, cmd *exec.Cmd) error {
+	cmd.Stdin = a.reader()
+	cmd.Stdout = a.writer()
+	cmd.Stderr = a.errWriter()
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exit.Code = exitErr.ExitCode()
+		return exit
+	}
+	return err
+}
+
+// pluginEnv returns the global flags in PluginFlags as NAME=value pairs, named
+// like the variables derived from EnvPrefix, or from the App name if it is not
+// set. Secret flags are left out.
+func (a *App) pluginEnv(c *Context) []string {
+	prefix := a.EnvPrefix
+	if prefix == "" {
+		prefix = filepath.Base(a.Name)
+	}
+	exported := make(map[string]bool)
+	for _, name := range a.PluginFlags {
+		exported[name] = true
+	}
+	var env []string
+	for _, f := range a.Flags {
+		if sf, ok := f.(secretFlag); ok && sf.secret() {
+			continue
+		}
+		listed := false
+		eachName(f.getName(), func(name string) {
+			listed = listed || exported[name]
+		})
+		if !listed {
+			continue
+		}
+		name := strings.Trim(strings.Split(f.getName(), ",")[0], " ")
+		if fl := c.globalSet.Lookup(name); fl != nil {
+			env = append(env, envVarName(prefix, nil, name)+"="+fl.Value.String())
+		}
+	}
+	return env
+}
+
+// executableName returns the name of an executable file, without the
+// extension on Windows.
+func executableName(info os.FileInfo) (string, bool) {
+	if info.IsDir() {
+		return "", false
+	}
+	name := info.Name()
+	if runtime.GOOS != "windows" {
+		return name, info.Mode()&0111 != 0
+	}
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		pathExt = ".com;.exe;.bat;.cmd"
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range filepath.SplitList(strings.ToLower(pathExt)) {
+		if ext != "" && ext == e {
+			return strings.TrimSuffix(name, filepath.Ext(name)), true
+		}
+	}
+	return "", false
+}
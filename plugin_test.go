@@ -0,0 +1,196 @@
+//go:build !windows
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func pluginDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]os.FileMode{
+		"ops-hello":  0755,
+		"ops-status": 0755,
+		"ops-notes":  0644,
+		"ops-":       0755,
+		"other-x":    0755,
+	}
+	script := "#!/bin/sh\necho \"$@\" \"$OPS_CLUSTER\" > \"$PLUGIN_OUT\"\nexit 3\n"
+	for name, mode := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestApp_Plugins(t *testing.T) {
+	dir := pluginDir(t)
+	defer os.RemoveAll(dir)
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnablePlugins = true
+	app.PluginDirs = []string{dir}
+	app.Commands = []Command{
+		{Name: "status", Action: func(c *Context) {}},
+	}
+	plugins := app.Plugins()
+	expect(t, len(plugins) > 0, true)
+	expect(t, plugins[0], Plugin{Name: "hello", Path: filepath.Join(dir, "ops-hello")})
+	for _, p := range plugins {
+		refute(t, p.Name, "status")
+		refute(t, p.Name, "notes")
+	}
+
+	app.EnablePlugins = false
+	expect(t, len(app.Plugins()), 0)
+}
+
+func TestApp_Plugin(t *testing.T) {
+	dir := pluginDir(t)
+	defer os.RemoveAll(dir)
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnablePlugins = true
+	app.PluginDirs = []string{dir}
+	app.Commands = []Command{
+		{Name: "status", Action: func(c *Context) {}},
+	}
+	expect(t, *app.plugin("hello"), Plugin{Name: "hello", Path: filepath.Join(dir, "ops-hello")})
+	for _, name := range []string{"status", "notes", "", "missing", "../" + filepath.Base(dir) + "/ops-hello"} {
+		if p := app.plugin(name); p != nil {
+			t.Errorf("expected no plugin %q, got %v", name, *p)
+		}
+	}
+
+	app.EnablePlugins = false
+	expect(t, app.plugin("hello") == nil, true)
+}
+
+func TestApp_PluginEnv(t *testing.T) {
+	app := NewApp()
+	app.Name = "ops"
+	app.Flags = []Flag{
+		StringFlag{Name: "cluster, c", Value: "dev"},
+		StringFlag{Name: "region", Value: "eu"},
+		StringFlag{Name: "token", Secret: true},
+	}
+	app.PluginFlags = []string{"c", "token"}
+	var env []string
+	app.Action = func(c *Context) {
+		env = app.pluginEnv(c)
+	}
+	expect(t, app.Run([]string{"ops", "--token", "hunter2", "-c", "prod"}), nil)
+	expect(t, strings.Join(env, " "), "OPS_CLUSTER=prod")
+}
+
+func TestApp_RunPlugin(t *testing.T) {
+	dir := pluginDir(t)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+	os.Setenv("PLUGIN_OUT", out)
+	defer os.Unsetenv("PLUGIN_OUT")
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnablePlugins = true
+	app.PluginDirs = []string{dir}
+	app.PluginFlags = []string{"cluster"}
+	app.Flags = []Flag{
+		StringFlag{Name: "cluster", Value: "dev"},
+	}
+	err := app.Run([]string{"ops", "--cluster", "prod", "hello", "world", "--loud"})
+	perr, ok := err.(*PluginExitError)
+	if !ok {
+		t.Fatalf("expected a *PluginExitError, got %v", err)
+	}
+	expect(t, perr.Code, 3)
+	expect(t, perr.Error(), "plugin 'hello' exited with status 3")
+
+	data, err := ioutil.ReadFile(out)
+	expect(t, err, nil)
+	expect(t, string(data), "world --loud prod\n")
+}
+
+func TestAppHelpListsPlugins(t *testing.T) {
+	dir := pluginDir(t)
+	defer os.RemoveAll(dir)
+
+	oldPrinter := HelpPrinter
+	defer func() {
+		HelpPrinter = oldPrinter
+	}()
+	var out bytes.Buffer
+	HelpPrinter = func(templ string, data interface{}) {
+		template.Must(template.New("help").Parse(templ)).Execute(&out, data)
+	}
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnablePlugins = true
+	app.PluginDirs = []string{dir}
+	app.Run([]string{"ops", "help"})
+	expect(t, strings.Contains(out.String(), "PLUGINS:"), false)
+
+	out.Reset()
+	app.ListPlugins = true
+	app.Run([]string{"ops", "help"})
+	if !strings.Contains(out.String(), "PLUGINS:\n   hello\t"+filepath.Join(dir, "ops-hello")+"\n") {
+		t.Errorf("expected the plugins in the help output, got %q", out.String())
+	}
+}
+
+func TestApp_RunShellAlias(t *testing.T) {
+	dir := pluginDir(t)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+
+	app := NewApp()
+	app.Name = "ops"
+	app.Aliases = map[string]string{
+		"note": "!echo noted > " + out + "; exit 4",
+	}
+	err := app.Run([]string{"ops", "note", "a", "b c"})
+	expect(t, err.Error(), "alias 'note' exited with status 4")
+
+	data, _ := ioutil.ReadFile(out)
+	expect(t, string(data), "noted\n")
+}
+
+func TestApp_RunScriptPlugin(t *testing.T) {
+	dir := pluginDir(t)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+	os.Setenv("PLUGIN_OUT", out)
+	defer os.Unsetenv("PLUGIN_OUT")
+	path := filepath.Join(dir, "script")
+	ioutil.WriteFile(path, []byte("hello world\n"), 0600)
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnablePlugins = true
+	app.PluginDirs = []string{dir}
+	app.PluginFlags = []string{"cluster"}
+	app.Flags = []Flag{
+		StringFlag{Name: "cluster", Value: "dev"},
+	}
+	app.EnableScripts = true
+	app.ErrWriter = ioutil.Discard
+	err := app.Run([]string{"ops", "--cluster", "prod", "run-script", path})
+	expect(t, err.Error(), "script failed at line 1: plugin 'hello' exited with status 3")
+
+	data, err := ioutil.ReadFile(out)
+	expect(t, err, nil)
+	expect(t, string(data), "world prod\n")
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"text/tabwriter"
+	"time"
+)
+
+// These hidden flags profile a run of the App. App.EnableProfiling adds them
+// to the global flags.
+var (
+	CPUProfileFlag = StringFlag{
+		Name:        "cpuprofile",
+		Description: "write a CPU profile to a file",
+		Hidden:      true,
+	}
+	MemProfileFlag = StringFlag{
+		Name:        "memprofile",
+		Description: "write a memory profile to a file when the run ends",
+		Hidden:      true,
+	}
+	TraceFlag = StringFlag{
+		Name:        "trace",
+		Description: "write an execution trace to a file",
+		Hidden:      true,
+	}
+	TimingFlag = BoolFlag{
+		Name:        "timing",
+		Description: "print how long each phase of the run took",
+		Hidden:      true,
+	}
+)
+
+// profilingFlags returns the profiling flags.
+func profilingFlags() []Flag {
+	return []Flag{CPUProfileFlag, MemProfileFlag, TraceFlag, TimingFlag}
+}
+
+// profiler writes the profiles and timings asked for by the profiling flags.
+// A nil profiler does nothing.
+type profiler struct {
+	w          io.Writer
+	start      time.Time
+	last       time.Time
+	phases     []phase
+	inAction   bool
+	timing     bool
+	cpuFile    *os.File
+	traceFile  *os.File
+	memProfile string
+}
+
+// phase is a part of a run and how long it took.
+type phase struct {
+	name     string
+	duration time.Duration
+}
+
+// startProfiling starts the profiles asked for by the flags of c. Timings are
+// measured from start. It returns nil if profiling is off.
+func (a *App) startProfiling(c *Context, start time.Time) (*profiler, error) {
+	if !a.EnableProfiling {
+		return nil, nil
+	}
+	p := &profiler{
+		w:          a.errWriter(),
+		start:      start,
+		last:       start,
+		timing:     c.GlobalBool(TimingFlag.Name),
+		memProfile: c.GlobalString(MemProfileFlag.Name),
+	}
+	if path := c.GlobalString(CPUProfileFlag.Name); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		p.cpuFile = f
+	}
+	if path := c.GlobalString(TraceFlag.Name); path != "" {
+		f, err := os.Create(path)
+		if err == nil {
+			err = trace.Start(f)
+		}
+		if err != nil {
+			if f != nil {
+				f.Close()
+			}
+			if p.cpuFile != nil {
+				pprof.StopCPUProfile()
+				p.cpuFile.Close()
+			}
+			return nil, err
+		}
+		p.traceFile = f
+	}
+	return p, nil
+}
+
+// mark ends the phase name, which began where the previous one ended.
+func (p *profiler) mark(name string) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.phases = append(p.phases, phase{name, now.Sub(p.last)})
+	p.last = now
+	p.inAction = false
+}
+
+// startAction begins the action phase, which ends with the next mark or with
+// stop.
+func (p *profiler) startAction() {
+	if p != nil {
+		p.inAction = true
+	}
+}
+
+// stop stops the profiles, writes the memory profile and prints the timings.
+// Failures are reported on the error writer.
+func (p *profiler) stop() {
+	if p == nil {
+		return
+	}
+	if p.inAction {
+		p.mark("action")
+	}
+	if p.cpuFile != nil {
+		pprof.StopCPUProfile()
+		p.cpuFile.Close()
+	}
+	if p.traceFile != nil {
+		trace.Stop()
+		p.traceFile.Close()
+	}
+	if p.memProfile != "" {
+		if err := writeHeapProfile(p.memProfile); err != nil {
+			fmt.Fprintf(p.w, "memory profile: %v\n", err)
+		}
+	}
+	if p.timing {
+		tw := tabwriter.NewWriter(p.w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "timing:")
+		for _, ph := range p.phases {
+			fmt.Fprintf(tw, "  %s\t%v\n", ph.name, ph.duration)
+		}
+		fmt.Fprintf(tw, "  total\t%v\n", p.last.Sub(p.start))
+		tw.Flush()
+	}
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	// collect garbage so that the profile shows live memory
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
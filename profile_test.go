@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestApp_ProfilingFlagsHidden(t *testing.T) {
+	var out bytes.Buffer
+	app := NewApp()
+	app.Writer = &out
+	app.EnableProfiling = true
+	expect(t, len(app.flags()), 5)
+	expect(t, app.Run([]string{"ops", "help"}), nil)
+	expect(t, strings.Contains(out.String(), "cpuprofile"), false)
+	expect(t, strings.Contains(out.String(), "timing"), false)
+
+	app.EnableProfiling = false
+	expect(t, app.Run([]string{"ops", "--timing"}) != nil, true)
+}
+
+var timingTests = []struct {
+	hooks    bool
+	expected string
+}{
+	{true, `^timing:\n  flags   \S+\n  before  \S+\n  action  \S+\n  after   \S+\n  total   \S+\n$`},
+	{false, `^timing:\n  flags   \S+\n  action  \S+\n  total   \S+\n$`},
+}
+
+func TestApp_Timing(t *testing.T) {
+	for _, test := range timingTests {
+		var errOut bytes.Buffer
+		app := NewApp()
+		app.ErrWriter = &errOut
+		app.EnableProfiling = true
+		if test.hooks {
+			app.Before = func(c *Context) error { return nil }
+			app.After = func(c *Context) error { return nil }
+		}
+		app.Commands = []Command{{Name: "work", Action: func(c *Context) {}}}
+
+		expect(t, app.Run([]string{"ops", "--timing", "work"}), nil)
+		if !regexp.MustCompile(test.expected).MatchString(errOut.String()) {
+			t.Errorf("unexpected timing output %q", errOut.String())
+		}
+	}
+}
+
+func TestApp_Profiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cpu, mem, trace := filepath.Join(dir, "cpu.out"), filepath.Join(dir, "mem.out"), filepath.Join(dir, "trace.out")
+
+	var errOut bytes.Buffer
+	app := NewApp()
+	app.ErrWriter = &errOut
+	app.EnableProfiling = true
+	app.Commands = []Command{
+		{
+			Name: "work",
+			Action: func(c *Context) {
+				total := 0
+				for i := 0; i < 1000; i++ {
+					total += len(strings.Repeat("x", i))
+				}
+			},
+		},
+	}
+	err = app.Run([]string{"ops", "--cpuprofile", cpu, "--memprofile", mem, "--trace", trace, "work"})
+	expect(t, err, nil)
+	expect(t, errOut.String(), "")
+	for _, path := range []string{cpu, mem, trace} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("expected %s not to be empty", path)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// This flag turns off prompting, for scripts. It is added to the global flags
+// when a flag of the App, or of one of its commands, has a prompt.
+var NoInputFlag = BoolFlag{
+	Name:        "no-input",
+	Description: "never prompt for input",
+}
+
+// ErrNoInput is returned by the prompts of Context when NoInputFlag is given.
+var ErrNoInput = errors.New("input is required, but prompting is turned off by --no-input")
+
+// promptFlag is implemented by flags that can be prompted for when they are
+// not given.
+type promptFlag interface {
+	Flag
+	// prompt returns the text to prompt with, or "" to never prompt
+	prompt() string
+	// hasEnvValue reports whether the flag gets a value from the environment
+	// or a file
+	hasEnvValue() bool
+}
+
+// hasPrompts reports whether a flag of the App or of one of its commands or
+// subcommands has a prompt.
+func (a *App) hasPrompts() bool {
+	prompts := func(flags []Flag) bool {
+		for _, f := range flags {
+			if pf, ok := f.(promptFlag); ok && pf.prompt() != "" {
+				return true
+			}
+		}
+		return false
+	}
+	if prompts(a.Flags) {
+		return true
+	}
+	for _, c := range a.Commands {
+		if prompts(c.Flags) {
+			return true
+		}
+		for _, s := range c.Subcommands {
+			if prompts(s.Flags) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Prompt asks for a line of text. An empty answer returns defaultValue.
+func (c *Context) Prompt(label, defaultValue string) (string, error) {
+	text := label + ": "
+	if defaultValue != "" {
+		text = fmt.Sprintf("%s [%s]: ", label, defaultValue)
+	}
+	answer, err := c.readAnswer(text, false)
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+// Confirm asks a yes or no question. An empty answer returns defaultValue.
+func (c *Context) Confirm(label string, defaultValue bool) (bool, error) {
+	text := label + " [y/N]: "
+	if defaultValue {
+		text = label + " [Y/n]: "
+	}
+	for {
+		answer, err := c.readAnswer(text, false)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return defaultValue, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(c.App.errWriter(), c.App.message("answer-yes-no"))
+	}
+}
+
+// Password asks for a secret, which is not echoed when typed in a terminal.
+func (c *Context) Password(label string) (string, error) {
+	return c.readAnswer(label+": ", true)
+}
+
+// Select asks for one of options, which are listed with numbers. The answer
+// can be a number or an option. An empty answer returns defaultValue, unless
+// it is empty too.
+func (c *Context) Select(label string, options []string, defaultValue string) (string, error) {
+	if c.noInput() {
+		return "", ErrNoInput
+	}
+	w := c.App.errWriter()
+	for i, option := range options {
+		fmt.Fprintf(w, "  %d) %s\n", i+1, option)
+	}
+	for {
+		answer, err := c.Prompt(label, defaultValue)
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return options[n-1], nil
+		}
+		for _, option := range options {
+			if answer == option {
+				return option, nil
+			}
+		}
+		fmt.Fprintf(w, c.App.message("choose-number")+"\n", len(options))
+	}
+}
+
+// readAnswer prints text and reads a line from the Reader of the App. The
+// input is read a byte at a time, so that nothing past the line is consumed.
+func (c *Context) readAnswer(text string, hidden bool) (string, error) {
+	if c.noInput() {
+		return "", ErrNoInput
+	}
+	r := newLineReader(c.App.reader(), c.App.errWriter())
+	r.in = bufio.NewReader(oneByteReader{c.App.reader()})
+	r.hidden = hidden
+	answer, err := r.readLine(text)
+	if err == io.EOF && answer == "" {
+		return "", errors.New("no answer: end of input")
+	}
+	return strings.TrimSpace(answer), err
+}
+
+func (c *Context) noInput() bool {
+	return lookupBool(NoInputFlag.Name, c.flagSet) || lookupBool(NoInputFlag.Name, c.globalSet)
+}
+
+// oneByteReader reads a byte at a time.
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o oneByteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return o.r.Read(p)
+}
+
+// isInteractive reports whether the Reader of the App is a terminal.
+func (a *App) isInteractive() bool {
+	f, ok := a.reader().(*os.File)
+	return ok && isTerminal(f.Fd())
+}
+
+// promptFlags prompts for the flags with a prompt that were neither given nor
+// set from the environment, until each gets a valid value. It does nothing
+// unless interactive is set and NoInputFlag is not given.
+func (c *Context) promptFlags(flags []Flag, interactive bool) error {
+	if !interactive || c.noInput() {
+		return nil
+	}
+	visited := make(map[string]bool)
+	c.flagSet.Visit(func(f *flag.Flag) {
+		visited[f.Name] = true
+	})
+
+	for _, f := range flags {
+		pf, ok := f.(promptFlag)
+		if !ok || pf.prompt() == "" || pf.hasEnvValue() {
+			continue
+		}
+		given := false
+		eachName(f.getName(), func(name string) {
+			given = given || visited[name] || visited["no-"+name]
+		})
+		if given {
+			continue
+		}
+
+		for {
+			value, err := c.promptFor(pf)
+			if err != nil {
+				return err
+			}
+			if err = setFlag(c.flagSet, f.getName(), value); err == nil {
+				break
+			}
+			fmt.Fprintf(c.App.errWriter(), c.App.message("invalid-value")+"\n", err)
+		}
+	}
+	return nil
+}
+
+// promptFor prompts for the value of f in the way that suits its type.
+func (c *Context) promptFor(f promptFlag) (string, error) {
+	name := strings.Trim(strings.Split(f.getName(), ",")[0], " ")
+	current := ""
+	if fl := c.flagSet.Lookup(name); fl != nil {
+		current = fl.Value.String()
+	}
+
+	switch f := f.(type) {
+	case BoolFlag:
+		yes, err := c.Confirm(f.Prompt, f.Value)
+		return strconv.FormatBool(yes), err
+	case ChoiceFlag:
+		return c.Select(f.Prompt, f.Allowed, f.Value)
+	}
+	if sf, ok := f.(secretFlag); ok && sf.secret() {
+		return c.Password(f.prompt())
+	}
+	return c.Prompt(f.prompt(), current)
+}
+
+// setFlag sets every form of a flag to value.
+func setFlag(set *flag.FlagSet, names, value string) error {
+	var err error
+	var last flag.Value
+	eachName(names, func(name string) {
+		fl := set.Lookup(name)
+		// forms of a flag that share a value are only set once
+		if err != nil || fl == nil || fl.Value == last {
+			return
+		}
+		last = fl.Value
+		err = set.Set(name, value)
+	})
+	return err
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func promptContext(t *testing.T, input string, flags []Flag, args ...string) (*Context, *bytes.Buffer) {
+	var out bytes.Buffer
+	app := NewApp()
+	app.Reader = strings.NewReader(input)
+	app.ErrWriter = &out
+	set, err := flagSet("app", append(flags, NoInputFlag), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return NewContext(app, set, set), &out
+}
+
+func TestContext_Prompts(t *testing.T) {
+	c, out := promptContext(t, "\nmaybe\nyes\ns3cr3t\n7\n2\nblue\n", nil)
+
+	name, err := c.Prompt("Name", "bob")
+	expect(t, err, nil)
+	expect(t, name, "bob")
+
+	ok, err := c.Confirm("Continue?", false)
+	expect(t, err, nil)
+	expect(t, ok, true)
+
+	password, err := c.Password("Password")
+	expect(t, err, nil)
+	expect(t, password, "s3cr3t")
+
+	color, err := c.Select("Color", []string{"red", "green", "blue"}, "")
+	expect(t, err, nil)
+	expect(t, color, "green")
+
+	color, err = c.Select("Color", []string{"red", "green", "blue"}, "red")
+	expect(t, err, nil)
+	expect(t, color, "blue")
+
+	_, err = c.Prompt("Name", "")
+	expect(t, err.Error(), "no answer: end of input")
+
+	expect(t, out.String(), "Name [bob]: "+
+		"Continue? [y/N]: Please answer yes or no.\nContinue? [y/N]: "+
+		"Password: "+
+		"  1) red\n  2) green\n  3) blue\nColor: Please choose a number from 1 to 3.\nColor: "+
+		"  1) red\n  2) green\n  3) blue\nColor [red]: "+
+		"Name: ")
+}
+
+func TestContext_PromptsWithNoInput(t *testing.T) {
+	c, _ := promptContext(t, "answer\n", nil, "--no-input")
+
+	_, err := c.Prompt("Name", "")
+	expect(t, err, ErrNoInput)
+	_, err = c.Select("Color", []string{"red"}, "")
+	expect(t, err, ErrNoInput)
+}
+
+func TestContext_PromptFlags(t *testing.T) {
+	os.Setenv("APP_REGION", "eu")
+	defer os.Unsetenv("APP_REGION")
+
+	flags := []Flag{
+		StringFlag{Name: "name, n", Prompt: "Name"},
+		IntFlag{Name: "replicas", Value: 1, Prompt: "Replicas"},
+		StringFlag{Name: "region", EnvVar: "APP_REGION", Prompt: "Region"},
+		StringFlag{Name: "token", Secret: true, Prompt: "Token"},
+		BoolFlag{Name: "force", Prompt: "Force?"},
+		ChoiceFlag{Name: "tier", Allowed: []string{"free", "pro"}, Prompt: "Tier"},
+		StringFlag{Name: "given", Prompt: "Given"},
+	}
+	c, out := promptContext(t, "web\nmany\n3\nabc\ny\n2\n", flags, "--given", "x")
+
+	expect(t, c.promptFlags(flags, true), nil)
+	expect(t, c.String("name"), "web")
+	expect(t, c.String("n"), "web")
+	expect(t, c.Int("replicas"), 3)
+	expect(t, c.String("region"), "eu")
+	expect(t, c.String("token"), "abc")
+	expect(t, c.Bool("force"), true)
+	expect(t, c.String("tier"), "pro")
+	expect(t, c.String("given"), "x")
+	expect(t, c.IsSet("replicas"), true)
+	expect(t, out.String(), "Name: Replicas [1]: Invalid value: expected an integer\nReplicas [1]: "+
+		"Token: Force? [y/N]:   1) free\n  2) pro\nTier: ")
+}
+
+func TestContext_PromptFlagsNotInteractive(t *testing.T) {
+	flags := []Flag{StringFlag{Name: "name", Value: "bob", Prompt: "Name"}}
+
+	c, out := promptContext(t, "web\n", flags)
+	expect(t, c.promptFlags(flags, false), nil)
+	expect(t, c.String("name"), "bob")
+
+	c, out = promptContext(t, "web\n", flags, "--no-input")
+	expect(t, c.promptFlags(flags, true), nil)
+	expect(t, c.String("name"), "bob")
+	expect(t, out.String(), "")
+}
+
+func TestApp_NoInputFlagAdded(t *testing.T) {
+	app := NewApp()
+	app.Writer = ioutil.Discard
+	expect(t, len(app.flags()), 1)
+
+	noInput := false
+	app.Commands = []Command{
+		{
+			Name: "deploy",
+			Subcommands: []Subcommand{
+				{
+					Name:  "api",
+					Flags: []Flag{StringFlag{Name: "region", Prompt: "Region"}},
+					Action: func(c *Context) {
+						noInput = c.noInput()
+					},
+				},
+			},
+		},
+	}
+	expect(t, len(app.flags()), 2)
+	expect(t, app.Run([]string{"app", "--no-input", "deploy", "api", "--region", "eu"}), nil)
+	expect(t, noInput, true)
+}
+
+func TestApp_NoInputFlagOverlap(t *testing.T) {
+	app := NewApp()
+	app.Writer = ioutil.Discard
+	app.Flags = []Flag{
+		BoolFlag{Name: "batch, no-input"},
+		StringFlag{Name: "region", Prompt: "Region"},
+	}
+	batch := false
+	app.Action = func(c *Context) {
+		batch = c.Bool("batch")
+	}
+	expect(t, len(app.flags()), 3)
+	expect(t, app.Run([]string{"app", "--no-input", "--region", "eu"}), nil)
+	expect(t, batch, true)
+}
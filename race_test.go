@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// These tests are meant to be run with the race detector: go test -race
+
+// lockedBuffer is a bytes.Buffer that can be written concurrently.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestApp_RunIsIdempotent(t *testing.T) {
+	var out bytes.Buffer
+	app := NewApp()
+	app.Name = "ops"
+	app.Writer = &out
+	app.EnableShell = true
+	app.Aliases = map[string]string{"st": "status --short"}
+	app.Flags = []Flag{
+		StringFlag{Name: "cluster", Value: "dev"},
+		StringFlag{Name: "env", Deprecated: "use --cluster instead"},
+	}
+	app.Commands = []Command{{Name: "status", Action: func(c *Context) {}}}
+
+	expect(t, app.Run([]string{"ops", "help"}), nil)
+	first := out.String()
+	expect(t, app.Run([]string{"ops", "help"}), nil)
+	expect(t, out.String(), first+first)
+	expect(t, len(app.Commands), 1)
+	expect(t, len(app.Flags), 2)
+}
+
+func TestApp_RunConcurrently(t *testing.T) {
+	var count int64
+	var out, errOut lockedBuffer
+	app := NewApp()
+	app.Name = "ops"
+	app.Writer = &out
+	app.ErrWriter = &errOut
+	app.EnableShell = true
+	app.Aliases = map[string]string{"st": "status --short"}
+	app.Flags = []Flag{
+		StringFlag{Name: "cluster", Value: "dev"},
+		StringFlag{Name: "env", Deprecated: "use --cluster instead"},
+	}
+	app.Commands = []Command{
+		{
+			Name:  "status",
+			Flags: []Flag{BoolFlag{Name: "short"}, IntFlag{Name: "limit", Value: 10}},
+			Action: func(c *Context) {
+				atomic.AddInt64(&count, int64(c.Int("limit")))
+				fmt.Fprintln(c.App.Writer, c.GlobalString("cluster"), c.Bool("short"))
+			},
+		},
+	}
+
+	argsList := [][]string{
+		{"ops", "--cluster", "prod", "status", "--limit", "1"},
+		{"ops", "st", "--limit", "2"},
+		{"ops", "--env", "qa", "status", "--limit", "3"},
+		{"ops", "help"},
+		{"ops", "help", "status"},
+		{"ops", "--version"},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, args := range argsList {
+			wg.Add(1)
+			go func(args []string) {
+				defer wg.Done()
+				if err := app.Run(args); err != nil {
+					t.Error(err)
+				}
+			}(args)
+		}
+	}
+	wg.Wait()
+
+	expect(t, atomic.LoadInt64(&count), int64(10*(1+2+3)))
+	expect(t, errOut.String(), "Warning: flag -env is deprecated: use --cluster instead\n")
+}
+
+func TestApp_HelpPrinterPerApp(t *testing.T) {
+	var out1, out2 bytes.Buffer
+	app1 := NewApp()
+	app1.Name = "one"
+	app1.Writer = &out1
+	app1.AppHelpTemplate = "{{.Name}} has {{len .Commands}} command\n"
+	app2 := NewApp()
+	app2.Name = "two"
+	app2.HelpPrinter = func(w io.Writer, templ string, data interface{}) {
+		fmt.Fprintf(&out2, "custom %s", data.(*App).Name)
+	}
+
+	expect(t, app1.Run([]string{"one", "help"}), nil)
+	expect(t, app2.Run([]string{"two", "help"}), nil)
+	expect(t, out1.String(), "one has 1 command\n")
+	expect(t, out2.String(), "custom two")
+}
+
+func TestApp_LiteralAppWarnsOnce(t *testing.T) {
+	var errOut lockedBuffer
+	app := &App{
+		Name:      "ops",
+		ErrWriter: &errOut,
+		Flags:     []Flag{StringFlag{Name: "env", Deprecated: "use --cluster instead"}},
+		Action:    func(c *Context) {},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := app.Run([]string{"ops", "--env", "qa"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	expect(t, errOut.String(), "Warning: flag -env is deprecated: use --cluster instead\n")
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// errInterrupted is returned by lineReader.readLine when Ctrl-C is pressed.
+var errInterrupted = errors.New("interrupted")
+
+// lineReader reads lines typed by a user. When reading from a terminal it
+// offers history with the up and down keys and tab completion; otherwise it
+// reads plain lines.
+type lineReader struct {
+	in      *bufio.Reader
+	out     io.Writer
+	fd      uintptr
+	isTerm  bool
+	history []string
+	// hidden lines are not echoed, for passwords
+	hidden bool
+	// complete returns the candidates for the last word of line
+	complete func(line string) []string
+}
+
+func newLineReader(in io.Reader, out io.Writer) *lineReader {
+	r := &lineReader{in: bufio.NewReader(in), out: out}
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		r.fd, r.isTerm = f.Fd(), true
+	}
+	return r
+}
+
+// readLine prints prompt and returns the next line without its line ending.
+// It returns io.EOF at the end of the input or when Ctrl-D is pressed on an
+// empty line.
+func (r *lineReader) readLine(prompt string) (string, error) {
+	if r.isTerm {
+		if state, err := makeRaw(r.fd); err == nil {
+			defer restoreTerminal(r.fd, state)
+			return r.readRawLine(prompt)
+		}
+	}
+
+	fmt.Fprint(r.out, prompt)
+	line, err := r.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func (r *lineReader) readRawLine(prompt string) (string, error) {
+	var line []rune
+	pos := len(r.history)
+	redraw := func() {
+		shown := string(line)
+		if r.hidden {
+			shown = ""
+		}
+		fmt.Fprintf(r.out, "\r\x1b[K%s%s", prompt, shown)
+	}
+	redraw()
+
+	for {
+		c, _, err := r.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch c {
+		case '\r', '\n':
+			fmt.Fprint(r.out, "\r\n")
+			return string(line), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(r.out, "^C\r\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(line) == 0 {
+				fmt.Fprint(r.out, "\r\n")
+				return "", io.EOF
+			}
+		case 21: // Ctrl-U
+			line = line[:0]
+			redraw()
+		case 8, 127: // backspace
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				redraw()
+			}
+		case '\t':
+			line = []rune(r.completeLine(string(line)))
+			redraw()
+		case 27: // escape sequences for the arrow keys
+			if b, _ := r.in.ReadByte(); b != '[' {
+				continue
+			}
+			switch b, _ := r.in.ReadByte(); b {
+			case 'A':
+				if pos > 0 {
+					pos--
+					line = []rune(r.history[pos])
+				}
+			case 'B':
+				if pos < len(r.history) {
+					pos++
+					line = nil
+					if pos < len(r.history) {
+						line = []rune(r.history[pos])
+					}
+				}
+			}
+			redraw()
+		default:
+			if c >= ' ' {
+				line = append(line, c)
+				if !r.hidden {
+					fmt.Fprint(r.out, string(c))
+				}
+			}
+		}
+	}
+}
+
+// completeLine completes the last word of line. A single candidate replaces
+// the word, several candidates are listed and their common prefix is used.
+func (r *lineReader) completeLine(line string) string {
+	if r.complete == nil {
+		return line
+	}
+	word := line[strings.LastIndexAny(line, " \t")+1:]
+	var matches []string
+	for _, c := range r.complete(line) {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return line
+	case 1:
+		return line[:len(line)-len(word)] + matches[0] + " "
+	}
+	prefix := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(word) {
+		return line[:len(line)-len(word)] + prefix
+	}
+	fmt.Fprintf(r.out, "\r\n%s\r\n", strings.Join(matches, "  "))
+	return line
+}
+
+// addHistory records a line so that it can be recalled with the up key.
+func (r *lineReader) addHistory(line string) {
+	if line == "" || (len(r.history) > 0 && r.history[len(r.history)-1] == line) {
+		return
+	}
+	r.history = append(r.history, line)
+}
@@ -0,0 +1,345 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// This flag selects the format of Context.Render. App.EnableOutput adds it,
+// ColumnsFlag and SortFlag to the global flags.
+var OutputFlag = StringFlag{
+	Name:        "output, o",
+	Value:       "table",
+	Description: "output format: table, json, yaml, csv or template=<go template>",
+}
+
+// This flag selects the columns shown by Context.Render in a table or CSV
+var ColumnsFlag = StringFlag{
+	Name:        "columns",
+	Description: "comma-separated columns to show",
+}
+
+// This flag sorts the rows of Context.Render
+var SortFlag = StringFlag{
+	Name:        "sort",
+	Description: "column to sort by, prefixed with - to sort in descending order",
+}
+
+// Render writes value, usually a struct or a slice of structs, to the Writer of
+// the App in the format chosen with OutputFlag: an aligned table with a row per
+// element and a column per field, JSON, YAML, CSV, or a Go template executed for
+// each element. ColumnsFlag and SortFlag select and sort the rows and columns.
+// Columns are named after the json tag of a field, or else the field name.
+func (c *Context) Render(value interface{}) error {
+	output := c.renderOption("output")
+	if output == "" {
+		output = OutputFlag.Value
+	}
+
+	items, list := renderItems(value)
+	columns := renderColumns(items)
+	if sortBy := c.renderOption("sort"); sortBy != "" {
+		if err := sortItems(items, columns, sortBy); err != nil {
+			return err
+		}
+	}
+	w := c.App.writer()
+
+	switch {
+	case output == "json" || output == "yaml":
+		var data []byte
+		var err error
+		v := value
+		if list {
+			v = itemValues(items)
+		}
+		if output == "json" {
+			data, err = json.MarshalIndent(v, "", "  ")
+			data = append(data, '\n')
+		} else {
+			data, err = marshalYAML(v)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case strings.HasPrefix(output, "template="):
+		t, err := template.New("output").Parse(strings.TrimPrefix(output, "template="))
+		if err != nil {
+			return fmt.Errorf("invalid output template: %v", err)
+		}
+		for _, item := range items {
+			var out strings.Builder
+			if err := t.Execute(&out, item.Interface()); err != nil {
+				return err
+			}
+			if !strings.HasSuffix(out.String(), "\n") {
+				out.WriteString("\n")
+			}
+			io.WriteString(w, out.String())
+		}
+		return nil
+	case output == "table" || output == "csv":
+		if selected := c.renderOption("columns"); selected != "" {
+			var err error
+			if columns, err = selectColumns(columns, selected); err != nil {
+				return err
+			}
+		}
+		if output == "csv" {
+			return writeCSV(w, items, columns)
+		}
+		writeTable(w, items, columns)
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, expected table, json, yaml, csv or template=<go template>", output)
+}
+
+// renderOption returns the value of a flag given to the command, or else given
+// to the App, or else the default of the flag of the command or of the App.
+func (c *Context) renderOption(name string) string {
+	for _, set := range []*flag.FlagSet{c.flagSet, c.globalSet} {
+		given := false
+		set.Visit(func(f *flag.Flag) {
+			given = given || f.Name == name
+		})
+		if given {
+			return lookupString(name, set)
+		}
+	}
+	if value := lookupString(name, c.flagSet); value != "" {
+		return value
+	}
+	return lookupString(name, c.globalSet)
+}
+
+// column is a column of rendered output.
+type column struct {
+	name string
+	// the value of the column in an element
+	cell func(item reflect.Value) reflect.Value
+}
+
+// renderItems returns the elements of value if it is a slice or array, or
+// else value itself.
+func renderItems(value interface{}) (items []reflect.Value, list bool) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, v.Index(i))
+		}
+		return items, true
+	}
+	if !v.IsValid() {
+		return nil, false
+	}
+	return []reflect.Value{v}, false
+}
+
+func itemValues(items []reflect.Value) []interface{} {
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		values[i] = item.Interface()
+	}
+	return values
+}
+
+// renderColumns returns the fields of struct elements, the keys of map
+// elements, or a single VALUE column for other elements.
+func renderColumns(items []reflect.Value) []column {
+	var sample reflect.Value
+	for _, item := range items {
+		if item = indirect(item); item.IsValid() {
+			sample = item
+			break
+		}
+	}
+
+	switch sample.Kind() {
+	case reflect.Struct:
+		var columns []column
+		t := sample.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if field.PkgPath != "" || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			index := i
+			columns = append(columns, column{name, func(item reflect.Value) reflect.Value {
+				if item = indirect(item); item.IsValid() {
+					return item.Field(index)
+				}
+				return item
+			}})
+		}
+		return columns
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		var names []string
+		for _, item := range items {
+			if item = indirect(item); !item.IsValid() {
+				continue
+			}
+			for _, key := range item.MapKeys() {
+				name := fmt.Sprint(key.Interface())
+				if _, ok := keys[name]; !ok {
+					keys[name] = key
+					names = append(names, name)
+				}
+			}
+		}
+		sort.Strings(names)
+		columns := make([]column, len(names))
+		for i, name := range names {
+			key := keys[name]
+			columns[i] = column{name, func(item reflect.Value) reflect.Value {
+				if item = indirect(item); item.IsValid() {
+					return item.MapIndex(key)
+				}
+				return item
+			}}
+		}
+		return columns
+	}
+	return []column{{"value", func(item reflect.Value) reflect.Value { return item }}}
+}
+
+// selectColumns returns the columns named in the comma-separated list selected.
+func selectColumns(columns []column, selected string) ([]column, error) {
+	var result []column
+	for _, name := range strings.Split(selected, ",") {
+		c, err := findColumn(columns, strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func findColumn(columns []column, name string) (column, error) {
+	var names []string
+	for _, c := range columns {
+		if strings.EqualFold(c.name, name) {
+			return c, nil
+		}
+		names = append(names, c.name)
+	}
+	return column{}, fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// sortItems sorts items by the column sortBy, in descending order if it
+// starts with -.
+func sortItems(items []reflect.Value, columns []column, sortBy string) error {
+	descending := strings.HasPrefix(sortBy, "-")
+	c, err := findColumn(columns, strings.TrimPrefix(sortBy, "-"))
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := c.cell(items[i]), c.cell(items[j])
+		if descending {
+			return lessCell(b, a)
+		}
+		return lessCell(a, b)
+	})
+	return nil
+}
+
+// lessCell orders numbers and times by value and everything else as text.
+func lessCell(a, b reflect.Value) bool {
+	a, b = indirect(a), indirect(b)
+	if !a.IsValid() || !b.IsValid() {
+		return !a.IsValid() && b.IsValid()
+	}
+	if a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.Struct:
+			if at, ok := a.Interface().(time.Time); ok {
+				return at.Before(b.Interface().(time.Time))
+			}
+		}
+	}
+	return formatCell(a) < formatCell(b)
+}
+
+// indirect follows pointers and interfaces, returning the zero Value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func formatCell(v reflect.Value) string {
+	if v.IsValid() && v.CanInterface() {
+		if s, ok := v.Interface().(fmt.Stringer); ok && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+			return s.String()
+		}
+	}
+	if v = indirect(v); !v.IsValid() || !v.CanInterface() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func writeTable(w io.Writer, items []reflect.Value, columns []column) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = strings.ToUpper(c.name)
+	}
+	fmt.Fprintln(tw, strings.Join(names, "\t"))
+	for _, item := range items {
+		fmt.Fprintln(tw, strings.Join(rowCells(item, columns), "\t"))
+	}
+	tw.Flush()
+}
+
+func writeCSV(w io.Writer, items []reflect.Value, columns []column) error {
+	cw := csv.NewWriter(w)
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	cw.Write(names)
+	for _, item := range items {
+		cw.Write(rowCells(item, columns))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func rowCells(item reflect.Value, columns []column) []string {
+	cells := make([]string, len(columns))
+	for i, c := range columns {
+		cells[i] = formatCell(c.cell(item))
+	}
+	return cells
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type renderHost struct {
+	Name    string    `json:"name"`
+	CPUs    int       `json:"cpus"`
+	Tags    []string  `json:"tags,omitempty"`
+	Started time.Time `json:"started"`
+	secret  string
+}
+
+var renderHosts = []renderHost{
+	{Name: "web-1", CPUs: 8, Tags: []string{"web", "eu"}, Started: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), secret: "x"},
+	{Name: "db-1", CPUs: 32, Started: time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)},
+	{Name: "web-10", CPUs: 4, Tags: []string{"web"}, Started: time.Date(2024, 6, 9, 0, 0, 0, 0, time.UTC)},
+}
+
+func render(t *testing.T, value interface{}, args ...string) (string, error) {
+	var out bytes.Buffer
+	var err error
+	app := NewApp()
+	app.Writer = &out
+	app.Flags = []Flag{OutputFlag}
+	app.Commands = []Command{
+		{
+			Name:  "hosts",
+			Flags: []Flag{ColumnsFlag, SortFlag},
+			Action: func(c *Context) {
+				err = c.Render(value)
+			},
+		},
+	}
+	if runErr := app.Run(append([]string{"app"}, args...)); runErr != nil {
+		t.Fatal(runErr)
+	}
+	return out.String(), err
+}
+
+var renderTests = []struct {
+	args     []string
+	expected string
+}{
+	{[]string{"hosts"}, "" +
+		"NAME    CPUS  TAGS      STARTED\n" +
+		"web-1   8     [web eu]  2024-03-01 00:00:00 +0000 UTC\n" +
+		"db-1    32    []        2023-01-15 00:00:00 +0000 UTC\n" +
+		"web-10  4     [web]     2024-06-09 00:00:00 +0000 UTC\n"},
+	{[]string{"hosts", "--columns", "name,CPUS", "--sort", "-cpus"}, "" +
+		"NAME    CPUS\n" +
+		"db-1    32\n" +
+		"web-1   8\n" +
+		"web-10  4\n"},
+	{[]string{"-o", "csv", "hosts", "--columns", "name,started", "--sort", "started"}, "" +
+		"name,started\n" +
+		"db-1,2023-01-15 00:00:00 +0000 UTC\n" +
+		"web-1,2024-03-01 00:00:00 +0000 UTC\n" +
+		"web-10,2024-06-09 00:00:00 +0000 UTC\n"},
+	{[]string{"-o", "template={{.Name}} has {{.CPUs}} CPUs", "hosts", "--sort", "name"}, "" +
+		"db-1 has 32 CPUs\n" +
+		"web-1 has 8 CPUs\n" +
+		"web-10 has 4 CPUs\n"},
+	{[]string{"-o", "json", "hosts", "--sort", "cpus"}, `[
+  {
+    "name": "web-10",
+    "cpus": 4,
+    "tags": [
+      "web"
+    ],
+    "started": "2024-06-09T00:00:00Z"
+  },
+  {
+    "name": "web-1",
+    "cpus": 8,
+    "tags": [
+      "web",
+      "eu"
+    ],
+    "started": "2024-03-01T00:00:00Z"
+  },
+  {
+    "name": "db-1",
+    "cpus": 32,
+    "started": "2023-01-15T00:00:00Z"
+  }
+]
+`},
+	{[]string{"--output", "yaml", "hosts"}, `- name: web-1
+  cpus: 8
+  tags:
+    - web
+    - eu
+  started: "2024-03-01T00:00:00Z"
+- name: db-1
+  cpus: 32
+  started: "2023-01-15T00:00:00Z"
+- name: web-10
+  cpus: 4
+  tags:
+    - web
+  started: "2024-06-09T00:00:00Z"
+`},
+}
+
+func TestContext_Render(t *testing.T) {
+	for _, test := range renderTests {
+		actual, err := render(t, renderHosts, test.args...)
+		expect(t, err, nil)
+		if actual != test.expected {
+			t.Errorf("%v: expected\n%s\ngot\n%s", test.args, test.expected, actual)
+		}
+	}
+}
+
+func TestApp_EnableOutput(t *testing.T) {
+	var out bytes.Buffer
+	app := NewApp()
+	app.Writer = &out
+	app.EnableOutput = true
+	app.Flags = []Flag{SortFlag}
+	app.Commands = []Command{
+		{
+			Name: "hosts",
+			Action: func(c *Context) {
+				expect(t, c.Render(renderHosts), nil)
+			},
+		},
+	}
+	expect(t, len(app.flags()), 4)
+	expect(t, app.Run([]string{"app", "-o", "csv", "--columns", "name", "--sort", "name", "hosts"}), nil)
+	expect(t, out.String(), "name\ndb-1\nweb-1\nweb-10\n")
+
+	app.EnableOutput = false
+	expect(t, len(app.flags()), 2)
+}
+
+func TestApp_EnableOutputWithCommandFlags(t *testing.T) {
+	var out bytes.Buffer
+	app := NewApp()
+	app.Writer = &out
+	app.EnableOutput = true
+	app.Commands = []Command{
+		{
+			Name:  "hosts",
+			Flags: []Flag{OutputFlag, ColumnsFlag},
+			Action: func(c *Context) {
+				expect(t, c.Render(renderHosts[1]), nil)
+			},
+		},
+	}
+	expect(t, app.Run([]string{"app", "-o", "csv", "--columns", "name", "hosts"}), nil)
+	expect(t, app.Run([]string{"app", "-o", "csv", "hosts", "--columns", "cpus"}), nil)
+	expect(t, app.Run([]string{"app", "-o", "csv", "hosts", "-o", "template={{.Name}}"}), nil)
+	expect(t, out.String(), "name\ndb-1\ncpus\n32\ndb-1\n")
+}
+
+func TestApp_EnableOutputOverlap(t *testing.T) {
+	output := ""
+	app := NewApp()
+	app.EnableOutput = true
+	app.Flags = []Flag{StringFlag{Name: "output", Value: "out.txt"}}
+	app.Action = func(c *Context) {
+		output = c.String("output")
+	}
+	expect(t, len(app.flags()), 4)
+	expect(t, app.Run([]string{"app"}), nil)
+	expect(t, output, "out.txt")
+}
+
+func TestContext_RenderValues(t *testing.T) {
+	actual, err := render(t, []string{"b", "a"}, "hosts", "--sort", "value")
+	expect(t, err, nil)
+	expect(t, actual, "VALUE\na\nb\n")
+
+	actual, err = render(t, map[string]interface{}{"name": "web-1", "cpus": 8}, "hosts")
+	expect(t, err, nil)
+	expect(t, actual, "CPUS  NAME\n8     web-1\n")
+
+	actual, err = render(t, &renderHosts[1], "hosts", "--columns", "name")
+	expect(t, err, nil)
+	expect(t, actual, "NAME\ndb-1\n")
+}
+
+func TestContext_RenderErrors(t *testing.T) {
+	_, err := render(t, renderHosts, "-o", "xml", "hosts")
+	expect(t, err.Error(), `unknown output format "xml", expected table, json, yaml, csv or template=<go template>`)
+
+	_, err = render(t, renderHosts, "hosts", "--sort", "memory")
+	expect(t, err.Error(), `unknown column "memory", expected one of name, cpus, tags, started`)
+
+	_, err = render(t, renderHosts, "-o", "template={{.Name", "hosts")
+	expect(t, err != nil, true)
+}
+
+func TestMarshalYAML(t *testing.T) {
+	data, err := marshalYAML(map[string]interface{}{
+		"empty":  []string{},
+		"nested": map[string]interface{}{"a": nil, "b": map[string]int{}},
+		"quoted": []string{"yes", "10", "a: b", "", " x", "multi\nline"},
+		"plain":  "hello world",
+		"lists":  [][]int{{1, 2}, {3}},
+	})
+	expect(t, err, nil)
+	expect(t, string(data), `empty: []
+lists:
+  - - 1
+    - 2
+  - - 3
+nested:
+  a: null
+  b: {}
+plain: hello world
+quoted:
+  - "yes"
+  - "10"
+  - "a: b"
+  - ""
+  - " x"
+  - "multi\nline"
+`)
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// maxResponseFileDepth is how deeply response files may name each other.
+const maxResponseFileDepth = 10
+
+// expandResponseFiles replaces every argument @path with the arguments read
+// from the file path, and @@arg with the literal @arg. A lone @ is left alone.
+func (a *App) expandResponseFiles(args []string) ([]string, error) {
+	return a.expandResponseFilesFrom(args, nil)
+}
+
+// expandResponseFilesFrom expands the response files of args, which were read
+// from the files of stack.
+func (a *App) expandResponseFilesFrom(args []string, stack []string) ([]string, error) {
+	var expanded []string
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "@@"):
+			expanded = append(expanded, arg[1:])
+		case strings.HasPrefix(arg, "@") && len(arg) > 1:
+			fileArgs, err := a.readResponseFile(arg[1:], stack)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, fileArgs...)
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+	return expanded, nil
+}
+
+// readResponseFile returns the expanded arguments of the response file path.
+func (a *App) readResponseFile(path string, stack []string) ([]string, error) {
+	stack = append(stack, path)
+	if len(stack) > maxResponseFileDepth {
+		return nil, fmt.Errorf("response files nested more than %d deep: %s", maxResponseFileDepth, strings.Join(stack, " -> "))
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("response file: %v", err)
+	}
+
+	var args []string
+	if a.ResponseFileLines {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSuffix(line, "\r"); line != "" {
+				args = append(args, line)
+			}
+		}
+	} else if args, err = tokenize(string(data)); err != nil {
+		return nil, fmt.Errorf("response file %s: %v", path, err)
+	}
+	return a.expandResponseFilesFrom(args, stack)
+}
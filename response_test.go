@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApp_RunResponseFiles(t *testing.T) {
+	nested := writeTempFile(t, "c.go\n'd e.go'\n")
+	defer os.Remove(nested)
+	path := writeTempFile(t, "--fast\na.go \"b c.go\"\n@"+nested+"\n@@literal\n")
+	defer os.Remove(path)
+
+	var args []string
+	var fast bool
+	app := NewApp()
+	app.EnableResponseFiles = true
+	app.Commands = []Command{
+		{
+			Name:  "lint",
+			Flags: []Flag{BoolFlag{Name: "fast"}},
+			Action: func(c *Context) {
+				args = c.Args()
+				fast = c.Bool("fast")
+			},
+		},
+	}
+
+	err := app.Run([]string{"app", "lint", "@" + path, "@", "@@x", "f.go"})
+	expect(t, err, nil)
+	expect(t, fast, true)
+	expected := []string{"a.go", "b c.go", "c.go", "d e.go", "@literal", "@", "@x", "f.go"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %q, got %q", expected, args)
+	}
+
+	err = app.Run([]string{"app", "lint", "@" + path + ".missing"})
+	if err == nil || !strings.HasPrefix(err.Error(), "response file: ") {
+		t.Errorf("expected an error about the missing file, got %v", err)
+	}
+}
+
+func TestApp_ResponseFileLines(t *testing.T) {
+	path := writeTempFile(t, "a b.go\r\n\nC:\\src\\c.go\n")
+	defer os.Remove(path)
+
+	app := NewApp()
+	app.ResponseFileLines = true
+	args, err := app.expandResponseFiles([]string{"@" + path})
+	expect(t, err, nil)
+	expected := []string{"a b.go", "C:\\src\\c.go"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %q, got %q", expected, args)
+	}
+}
+
+func TestApp_ResponseFileDepth(t *testing.T) {
+	path := writeTempFile(t, "")
+	defer os.Remove(path)
+	ioutil.WriteFile(path, []byte("@"+path), 0600)
+
+	app := NewApp()
+	_, err := app.expandResponseFiles([]string{"@" + path})
+	if err == nil || !strings.HasPrefix(err.Error(), "response files nested more than 10 deep: "+path+" -> "+path) {
+		t.Errorf("expected a nesting error, got %v", err)
+	}
+}
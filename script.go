@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var scriptCommand = Command{
+	Name:             "run-script",
+	ShortDescription: "Runs the commands in a file, or in stdin",
+	Usage:            "run-script [--keep-going] [<file>]",
+	Description: `Runs one command per line, as if each had been given on the command line after
+   the global options. Empty lines and lines starting with # are skipped, and
+   $NAME or ${NAME} is replaced by the environment variable NAME outside single
+   quotes. Stops at the first failing command unless --keep-going is given.`,
+	Flags: []Flag{
+		BoolFlag{Name: "keep-going, k", Description: "run the remaining commands after one fails"},
+	},
+}
+
+func init() {
+	// set here since runScript depends on App.Command, which lists scriptCommand
+	scriptCommand.run = func(c *Context) error {
+		if c.lineRunner == scriptCommand.Name {
+			return errors.New(c.App.message("nested-script"))
+		}
+		in := c.App.reader()
+		if path := c.Args().First(); path != "" && path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		return c.App.runScript(c, in, c.Bool("keep-going"))
+	}
+}
+
+// scriptFailure is a command of a script that returned an error.
+type scriptFailure struct {
+	line int
+	text string
+	err  error
+}
+
+// runScript runs the command lines read from r. Unless keepGoing is set it
+// stops at the first failure. Failures are summarized on the error writer.
+func (a *App) runScript(c *Context, r io.Reader, keepGoing bool) error {
+	var failures []scriptFailure
+	commands := 0
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		commands++
+
+		args, err := tokenizeExpand(text, os.Getenv)
+		if err == nil && len(args) > 0 {
+			err = a.runCommandLine(c, scriptCommand.Name, args)
+		}
+		if err != nil {
+			failures = append(failures, scriptFailure{lineNo, text, err})
+			if !keepGoing {
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	w := a.errWriter()
+	fmt.Fprintf(w, a.message("script-failures")+"\n", len(failures), commands)
+	for _, f := range failures {
+		fmt.Fprintf(w, a.message("script-failure")+"\n", f.line, f.text, f.err)
+	}
+	if len(failures) == 1 {
+		return fmt.Errorf(a.message("script-failed-at"), failures[0].line, failures[0].err)
+	}
+	return fmt.Errorf(a.message("script-failed"), len(failures))
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testScript = `# scale the services
+scale web $OPS_REPLICAS
+
+fail
+scale "worker ${OPS_REPLICAS}"
+bogus
+`
+
+func TestApp_RunScript(t *testing.T) {
+	var calls []string
+	var errOut bytes.Buffer
+	os.Setenv("OPS_REPLICAS", "3")
+	defer os.Unsetenv("OPS_REPLICAS")
+
+	path := writeTempFile(t, testScript)
+	defer os.Remove(path)
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnableScripts = true
+	app.ErrWriter = &errOut
+	app.Flags = []Flag{
+		StringFlag{Name: "cluster", Value: "dev"},
+	}
+	app.Commands = []Command{
+		{
+			Name: "scale",
+			Action: func(c *Context) {
+				calls = append(calls, c.GlobalString("cluster")+": scale "+strings.Join(c.Args(), " "))
+			},
+		},
+		{
+			Name: "fail",
+			Before: func(c *Context) error {
+				return errors.New("failed on purpose")
+			},
+			Action: func(c *Context) {},
+		},
+	}
+
+	err := app.Run([]string{"ops", "--cluster", "prod", "run-script", path})
+	expect(t, err.Error(), "script failed at line 4: failed on purpose")
+	expect(t, strings.Join(calls, "|"), "prod: scale web 3")
+	expect(t, errOut.String(), "1 of 2 commands failed:\n   line 4: fail\n      failed on purpose\n")
+
+	// --keep-going runs the rest of the script
+	calls = nil
+	errOut.Reset()
+	err = app.Run([]string{"ops", "run-script", "-k", path})
+	expect(t, err.Error(), "script failed: 2 commands failed")
+	expect(t, strings.Join(calls, "|"), "dev: scale web 3|dev: scale worker 3")
+	expect(t, errOut.String(), "2 of 4 commands failed:\n"+
+		"   line 4: fail\n      failed on purpose\n"+
+		"   line 6: bogus\n      Unknown command 'bogus' - type 'help' for a list of commands\n")
+}
+
+func TestApp_RunScriptFromStdin(t *testing.T) {
+	var calls []string
+	var errOut bytes.Buffer
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = oldStdin
+	}()
+	w.WriteString("scale api 2\n")
+	w.Close()
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnableScripts = true
+	app.ErrWriter = &errOut
+	app.Commands = []Command{
+		{
+			Name: "scale",
+			Action: func(c *Context) {
+				calls = append(calls, "scale "+strings.Join(c.Args(), " "))
+			},
+		},
+	}
+
+	err = app.Run([]string{"ops", "run-script"})
+	expect(t, err, nil)
+	expect(t, strings.Join(calls, "|"), "scale api 2")
+}
+
+func TestApp_RunScriptMissingFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cli")
+	defer os.RemoveAll(dir)
+
+	app := NewApp()
+	app.EnableScripts = true
+	err := app.Run([]string{"ops", "run-script", dir + "/missing"})
+	expect(t, os.IsNotExist(err), true)
+}
+
+func TestApp_RunScriptAliasesAndResponseFiles(t *testing.T) {
+	var calls []string
+	var errOut bytes.Buffer
+	args := writeTempFile(t, "db cache\n")
+	defer os.Remove(args)
+	path := writeTempFile(t, "up web\nscale @"+args+"\n")
+	defer os.Remove(path)
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnableScripts = true
+	app.ErrWriter = &errOut
+	app.Commands = []Command{
+		{
+			Name: "scale",
+			Action: func(c *Context) {
+				calls = append(calls, "scale "+strings.Join(c.Args(), " "))
+			},
+		},
+	}
+	app.Aliases = map[string]string{"up": "scale --"}
+	app.EnableResponseFiles = true
+	err := app.Run([]string{"ops", "run-script", path})
+	expect(t, err, nil)
+	expect(t, strings.Join(calls, "|"), "scale web|scale db cache")
+}
+
+func TestApp_RunScriptNested(t *testing.T) {
+	var calls []string
+	var errOut bytes.Buffer
+	path := writeTempFile(t, "")
+	defer os.Remove(path)
+	ioutil.WriteFile(path, []byte("scale web\nrun-script "+path+"\n"), 0600)
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnableScripts = true
+	app.ErrWriter = &errOut
+	app.Commands = []Command{
+		{
+			Name: "scale",
+			Action: func(c *Context) {
+				calls = append(calls, "scale "+strings.Join(c.Args(), " "))
+			},
+		},
+	}
+	app.EnableShell = true
+	err := app.Run([]string{"ops", "run-script", path})
+	expect(t, err.Error(), "script failed at line 2: run-script cannot be run from a script")
+	expect(t, strings.Join(calls, "|"), "scale web")
+
+	calls = nil
+	errOut.Reset()
+	app.Reader = strings.NewReader("run-script " + path + "\n")
+	app.Writer = ioutil.Discard
+	expect(t, app.Run([]string{"ops", "shell"}), nil)
+	expect(t, strings.Join(calls, "|"), "scale web")
+	expect(t, strings.HasSuffix(errOut.String(), "\nscript failed at line 2: run-script cannot be run from a script\n"), true)
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// The name of the command added by App.EnableShell
+	shellCommandName = "shell"
+	// The number of lines kept in the shell history file
+	shellHistorySize = 1000
+)
+
+var shellCommand = Command{
+	Name:             shellCommandName,
+	ShortDescription: "Starts an interactive shell for running commands",
+	Description:      "Reads commands line by line and runs them. Type 'exit' or press Ctrl-D to leave.",
+}
+
+func init() {
+	// set here since runShell depends on App.Command, which lists shellCommand
+	shellCommand.run = func(c *Context) error {
+		if c.lineRunner != "" {
+			return fmt.Errorf(c.App.message("unknown-command"), shellCommandName)
+		}
+		return c.App.runShell(c, c.App.reader(), c.App.writer())
+	}
+}
+
+// runShell reads command lines from in and runs them until 'exit', 'quit' or
+// the end of the input. The global flags parsed for c are used by every
+// command. Errors are reported and do not end the shell.
+func (a *App) runShell(c *Context, in io.Reader, out io.Writer) error {
+	r := newLineReader(in, out)
+	r.complete = a.shellCompletions
+	r.history = a.loadShellHistory()
+
+	prompt := a.ShellPrompt
+	if prompt == "" {
+		prompt = filepath.Base(a.Name) + "> "
+	}
+
+	for {
+		line, err := r.readLine(prompt)
+		if err == errInterrupted {
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		args, err := tokenize(line)
+		if err != nil {
+			fmt.Fprintln(a.errWriter(), err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		r.addHistory(line)
+		a.appendShellHistory(line, args)
+
+		if args[0] == "exit" || args[0] == "quit" {
+			return nil
+		}
+		if err := a.runCommandLine(c, shellCommandName, args); err != nil {
+			fmt.Fprintln(a.errWriter(), err)
+		}
+	}
+}
+
+// runCommandLine runs the alias, command or plugin named by args[0] as if it
+// had been given on the command line after the global flags of c, the context
+// of the shell or run-script command named runner.
+func (a *App) runCommandLine(c *Context, runner string, args []string) (err error) {
+	defer a.recoverPanic(append([]string{a.Name}, args...), &err)
+
+	if a.EnableResponseFiles {
+		if args, err = a.expandResponseFiles(args); err != nil {
+			return err
+		}
+	}
+
+	set := flag.NewFlagSet(a.Name, flag.ContinueOnError)
+	set.Parse(append([]string{"--"}, args...))
+	context := NewContext(a, set, c.globalSet)
+	context.ctx = c.ctx
+	context.logger = c.logger
+	context.lineRunner = runner
+	if c.lineRunner == scriptCommand.Name {
+		context.lineRunner = scriptCommand.Name
+	}
+	if ok, err := a.dispatch(context); ok {
+		return err
+	}
+	return fmt.Errorf(a.message("unknown-command"), args[0])
+}
+
+// shellCompletions returns the completion candidates for the last word of a
+// shell line.
+func (a *App) shellCompletions(line string) []string {
+	args, err := tokenize(line)
+	if err != nil {
+		return nil
+	}
+	if len(args) > 0 && !strings.HasSuffix(line, " ") {
+		args = args[:len(args)-1]
+	}
+	if len(args) == 0 {
+		var names []string
+		for _, name := range commandNames(visibleCommands(a.commands())) {
+			if name != shellCommandName {
+				names = append(names, name)
+			}
+		}
+		return append(names, "exit")
+	}
+	return a.completions(args)
+}
+
+func (a *App) loadShellHistory() []string {
+	if a.ShellHistoryFile == "" {
+		return nil
+	}
+	f, err := os.Open(a.ShellHistoryFile)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var history []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		history = append(history, scanner.Text())
+	}
+	if len(history) > shellHistorySize {
+		history = history[len(history)-shellHistorySize:]
+	}
+	return history
+}
+
+// appendShellHistory adds line, split into args, to ShellHistoryFile, unless
+// it gives a value to a secret flag.
+func (a *App) appendShellHistory(line string, args []string) {
+	if a.ShellHistoryFile == "" {
+		return
+	}
+	args = append([]string{a.Name}, args...)
+	for i, arg := range a.redactArgs(args) {
+		if arg != args[i] {
+			return
+		}
+	}
+	f, err := os.OpenFile(a.ShellHistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
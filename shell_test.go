@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApp_RunShell(t *testing.T) {
+	var calls []string
+	var out, errOut bytes.Buffer
+	dir, _ := ioutil.TempDir("", "cli")
+	defer os.RemoveAll(dir)
+
+	app := NewApp()
+	app.Name = "ops"
+	app.EnableShell = true
+	app.Flags = []Flag{
+		StringFlag{Name: "cluster", Value: "dev"},
+	}
+	app.Commands = []Command{
+		{
+			Name:  "status",
+			Flags: []Flag{BoolFlag{Name: "short"}},
+			Action: func(c *Context) {
+				calls = append(calls, "status "+c.GlobalString("cluster")+" "+strings.Join(c.Args(), ","))
+				if c.Bool("short") {
+					calls = append(calls, "short")
+				}
+			},
+		},
+		{
+			Name: "deploy",
+			Action: func(c *Context) {
+				panic("deploy failed")
+			},
+		},
+	}
+	app.ErrWriter = &errOut
+	app.RecoverPanics = true
+	app.ShellHistoryFile = filepath.Join(dir, "history")
+	app.Action = func(c *Context) {
+		in := strings.NewReader("status --short 'a b'\n\n  status x  \nbogus\nshell\nsay 'oops\ndeploy\nexit\nstatus\n")
+		expect(t, app.runShell(c, in, &out), nil)
+	}
+
+	err := app.Run([]string{"ops", "--cluster", "prod"})
+	expect(t, err, nil)
+	expect(t, strings.Join(calls, "|"), "status prod a b|short|status prod x")
+	expect(t, out.String(), strings.Repeat("ops> ", 8))
+	expect(t, errOut.String(), "Unknown command 'bogus' - type 'help' for a list of commands\n"+
+		"Unknown command 'shell' - type 'help' for a list of commands\n"+
+		"unterminated single quote\n"+
+		"ops crashed unexpectedly: deploy failed\n")
+
+	history, _ := ioutil.ReadFile(app.ShellHistoryFile)
+	expect(t, string(history), "status --short 'a b'\n  status x  \nbogus\nshell\ndeploy\nexit\n")
+}
+
+func TestApp_RunShellHistory(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cli")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "history")
+	ioutil.WriteFile(path, []byte("status\ndeploy\n"), 0600)
+
+	app := NewApp()
+	app.ShellHistoryFile = path
+	expect(t, reflect.DeepEqual(app.loadShellHistory(), []string{"status", "deploy"}), true)
+}
+
+func TestApp_RunShellHistorySecrets(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cli")
+	defer os.RemoveAll(dir)
+
+	app := NewApp()
+	app.Name = "ops"
+	app.ErrWriter = ioutil.Discard
+	app.ShellHistoryFile = filepath.Join(dir, "history")
+	app.Flags = []Flag{StringFlag{Name: "token, t", Secret: true}}
+	app.Commands = []Command{
+		{Name: "login", Flags: []Flag{StringFlag{Name: "password", Secret: true}}, Action: func(c *Context) {}},
+	}
+	app.Action = func(c *Context) {
+		in := strings.NewReader("login --password s3cret\nlogin\nlogin --password=s3cret\n-t abc login\nexit\n")
+		expect(t, app.runShell(c, in, ioutil.Discard), nil)
+	}
+	expect(t, app.Run([]string{"ops"}), nil)
+
+	history, _ := ioutil.ReadFile(app.ShellHistoryFile)
+	expect(t, string(history), "login\nexit\n")
+}
+
+var shellCompletionTests = []struct {
+	line     string
+	expected []string
+}{
+	{"", []string{"status", "deploy", "help", "exit"}},
+	{"st", []string{"status", "deploy", "help", "exit"}},
+	{"status ", []string{"--short"}},
+	{"status --sh", []string{"--short"}},
+}
+
+func TestApp_ShellCompletions(t *testing.T) {
+	app := NewApp()
+	app.Commands = []Command{
+		{Name: "status", Flags: []Flag{BoolFlag{Name: "short"}}},
+		{Name: "deploy"},
+		helpCommand,
+		shellCommand,
+	}
+
+	for _, test := range shellCompletionTests {
+		actual := app.shellCompletions(test.line)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("completions for %q: expected %v, got %v", test.line, test.expected, actual)
+		}
+	}
+}
+
+func TestLineReader_CompleteLine(t *testing.T) {
+	var out bytes.Buffer
+	r := newLineReader(strings.NewReader(""), &out)
+	r.complete = func(line string) []string {
+		return []string{"status", "stats", "deploy"}
+	}
+
+	expect(t, r.completeLine("de"), "deploy ")
+	expect(t, r.completeLine("s"), "stat")
+	expect(t, r.completeLine("stat"), "stat")
+	expect(t, out.String(), "\r\nstatus  stats\r\n")
+}
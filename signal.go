@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// exit is replaced in tests.
+var exit = os.Exit
+
+// handleSignals returns a copy of parent that is cancelled on the first SIGINT
+// or SIGTERM. A second signal, or ShutdownTimeout passing after the first,
+// exits the process. The returned function stops the signal handling.
+func (a *App) handleSignals(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-signals:
+		case <-done:
+			return
+		}
+		fmt.Fprintf(a.errWriter(), a.message("signal-stopping")+"\n", sig)
+		cancel()
+
+		var timeout <-chan time.Time
+		if a.ShutdownTimeout > 0 {
+			timer := time.NewTimer(a.ShutdownTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case sig = <-signals:
+			fmt.Fprintf(a.errWriter(), a.message("signal-exiting")+"\n", sig)
+		case <-timeout:
+			fmt.Fprintf(a.errWriter(), a.message("shutdown-timeout")+"\n", a.ShutdownTimeout)
+		case <-done:
+			return
+		}
+		exit(signalExitCode(sig))
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// signalExitCode follows the shell convention of 128 plus the signal number.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
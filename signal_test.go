@@ -0,0 +1,62 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestApp_HandleSignals(t *testing.T) {
+	var out bytes.Buffer
+	cancelled := false
+
+	app := NewApp()
+	app.ErrWriter = &out
+	app.HandleSignals = true
+	app.Action = func(c *Context) {
+		syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+		select {
+		case <-c.Context().Done():
+			cancelled = true
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	err := app.Run([]string{"app"})
+	expect(t, err, nil)
+	expect(t, cancelled, true)
+	expect(t, out.String(), "Received interrupt, stopping (repeat to exit immediately)\n")
+}
+
+func TestApp_HandleSignalsTimeout(t *testing.T) {
+	oldExit := exit
+	defer func() {
+		exit = oldExit
+	}()
+	exited := make(chan int, 1)
+	exit = func(code int) {
+		exited <- code
+	}
+
+	var out bytes.Buffer
+	app := NewApp()
+	app.ErrWriter = &out
+	app.HandleSignals = true
+	app.ShutdownTimeout = 10 * time.Millisecond
+	app.Action = func(c *Context) {
+		syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+		select {
+		case code := <-exited:
+			expect(t, code, 143)
+		case <-time.After(5 * time.Second):
+			t.Errorf("expected the app to exit after the shutdown timeout")
+		}
+	}
+
+	app.Run([]string{"app"})
+	expect(t, out.String(), "Received terminated, stopping (repeat to exit immediately)\nDid not stop within 10ms, exiting\n")
+}
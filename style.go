@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// This flag turns off colors, as does a non-empty NO_COLOR environment variable.
+// App.ColorHelp adds it to the global flags.
+var NoColorFlag = BoolFlag{
+	Name:        "no-color",
+	Description: "do not colorize output",
+}
+
+// ANSI escape sequences used to style help.
+const (
+	styleHeading = "\x1b[1m"
+	styleName    = "\x1b[36m"
+	styleReset   = "\x1b[0m"
+)
+
+// minWrapWidth is the narrowest a description column is wrapped to. Narrower
+// columns start descriptions on a line of their own.
+const minWrapWidth = 20
+
+// helpHeading matches the headings of the help templates, such as "OPTIONS:".
+var helpHeading = regexp.MustCompile(`^\p{Lu}[\p{Lu} -]*:$`)
+
+// terminalSize returns the size of the Writer of the App if it is a terminal.
+// The COLUMNS and LINES environment variables override the detected size.
+func (a *App) terminalSize() (width, height int, ok bool) {
+	f, ok := a.writer().(*os.File)
+	if !ok || !isTerminal(f.Fd()) {
+		return 0, 0, false
+	}
+	width, height, ok = terminalSize(f.Fd())
+	if !ok {
+		width, height = 80, 24
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		width = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		height = n
+	}
+	return width, height, true
+}
+
+// useColor reports whether output to a terminal may be colorized: ColorHelp
+// is set and neither NO_COLOR nor NoColorFlag is.
+func (c *Context) useColor() bool {
+	if !c.App.ColorHelp || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return !lookupBool(NoColorFlag.Name, c.flagSet) && !lookupBool(NoColorFlag.Name, c.globalSet)
+}
+
+// printStyledHelp executes the help template templ with data and writes the
+// result to w, formatted with formatHelp.
+func printStyledHelp(w io.Writer, templ string, data interface{}, width int, color bool) {
+	var buf bytes.Buffer
+	t := template.Must(template.New("help").Parse(templ))
+	if err := t.Execute(&buf, data); err != nil {
+		panic(err)
+	}
+	io.WriteString(w, formatHelp(buf.String(), width, color))
+}
+
+// formatHelp aligns the tab-separated columns of text with spaces and wraps
+// it to width. Wrapped lines are indented as far as the text they continue,
+// so descriptions hang under the description column. With color, headings
+// are bold and the names of commands and flags are colored.
+func formatHelp(text string, width int, color bool) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	for i := 0; i < len(lines); {
+		if !strings.Contains(lines[i], "\t") {
+			line := lines[i]
+			if color && helpHeading.MatchString(line) {
+				line = styleHeading + line + styleReset
+			}
+			out = append(out, wrapLine(line, width)...)
+			i++
+			continue
+		}
+		// consecutive lines with columns are aligned together, like tabwriter
+		j := i
+		for j < len(lines) && strings.Contains(lines[j], "\t") {
+			j++
+		}
+		out = append(out, formatColumns(lines[i:j], width, color)...)
+		i = j
+	}
+	return strings.Join(out, "\n")
+}
+
+// formatColumns aligns rows of tab-separated cells and wraps the last cell of
+// each row.
+func formatColumns(rows []string, width int, color bool) []string {
+	var widths []int
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = strings.Split(row, "\t")
+		for k, cell := range cells[i][:len(cells[i])-1] {
+			if k == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := textWidth(cell); n > widths[k] {
+				widths[k] = n
+			}
+		}
+	}
+
+	var out []string
+	for _, row := range cells {
+		var prefix strings.Builder
+		indent := 0
+		for k, cell := range row[:len(row)-1] {
+			pad := widths[k] - textWidth(cell) + 2
+			if name := strings.TrimSpace(cell); k == 0 && color && name != "" {
+				start := strings.Index(cell, name)
+				cell = cell[:start] + styleName + name + styleReset + cell[start+len(name):]
+			}
+			prefix.WriteString(cell + strings.Repeat(" ", pad))
+			indent += widths[k] + 2
+		}
+		description := row[len(row)-1]
+		if width-indent < minWrapWidth {
+			// too narrow to hang, so the description goes below the names
+			out = append(out, strings.TrimRight(prefix.String(), " "))
+			out = append(out, wrapText(description, width, "      ")...)
+			continue
+		}
+		wrapped := wrapText(description, width, strings.Repeat(" ", indent))
+		wrapped[0] = prefix.String() + strings.TrimLeft(wrapped[0], " ")
+		out = append(out, wrapped...)
+	}
+	return out
+}
+
+// wrapLine wraps line to width, indenting continuation lines as far as line
+// is indented.
+func wrapLine(line string, width int) []string {
+	text := strings.TrimLeft(line, " ")
+	return wrapText(text, width, line[:len(line)-len(text)])
+}
+
+// wrapText breaks text into lines of at most width columns where it can, each
+// starting with indent. Words longer than a line are not broken.
+func wrapText(text string, width int, indent string) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{indent + text}
+	}
+	var lines []string
+	line := indent + words[0]
+	for _, word := range words[1:] {
+		if textWidth(line)+1+textWidth(word) > width {
+			lines = append(lines, line)
+			line = indent + word
+		} else {
+			line += " " + word
+		}
+	}
+	return append(lines, line)
+}
+
+// ansiEscape matches the escape sequences that style text.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// textWidth returns the number of columns text takes up in a terminal.
+func textWidth(text string) int {
+	return utf8.RuneCountInString(ansiEscape.ReplaceAllString(text, ""))
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText("the quick brown fox jumps over the lazy dog", 20, "  ")
+	expect(t, strings.Join(lines, "\n"), "  the quick brown\n  fox jumps over the\n  lazy dog")
+}
+
+func TestWrapText_LongWord(t *testing.T) {
+	lines := wrapText("a supercalifragilistic word", 10, "")
+	expect(t, strings.Join(lines, "\n"), "a\nsupercalifragilistic\nword")
+}
+
+func TestFormatHelp_HangingIndent(t *testing.T) {
+	text := "COMMANDS:\n   add\tAdds a file to the index so that it is committed\n   rm\tRemoves a file\n"
+	expected := "COMMANDS:\n" +
+		"   add  Adds a file to the index\n" +
+		"        so that it is committed\n" +
+		"   rm   Removes a file\n"
+	expect(t, formatHelp(text, 32, false), expected)
+}
+
+func TestFormatHelp_WrapsParagraphs(t *testing.T) {
+	text := "DESCRIPTION:\n   Shows the commits that are reachable from the given ones\n"
+	expected := "DESCRIPTION:\n   Shows the commits that are\n   reachable from the given ones\n"
+	expect(t, formatHelp(text, 34, false), expected)
+}
+
+func TestFormatHelp_Narrow(t *testing.T) {
+	text := "   --a-very-long-flag-name value\tdoes a thing\n"
+	expected := "   --a-very-long-flag-name value\n      does a thing\n"
+	expect(t, formatHelp(text, 40, false), expected)
+}
+
+func TestFormatHelp_Color(t *testing.T) {
+	text := "OPTIONS:\n   --verbose\tsay more\n"
+	expected := "\x1b[1mOPTIONS:\x1b[0m\n   \x1b[36m--verbose\x1b[0m  say more\n"
+	expect(t, formatHelp(text, 80, true), expected)
+}
+
+func TestContext_UseColor(t *testing.T) {
+	os.Unsetenv("NO_COLOR")
+	set := flag.NewFlagSet("test", 0)
+	NoColorFlag.Apply(set)
+	app := &App{}
+	c := NewContext(app, set, set)
+	expect(t, c.useColor(), false)
+
+	app.ColorHelp = true
+	expect(t, c.useColor(), true)
+
+	os.Setenv("NO_COLOR", "1")
+	expect(t, c.useColor(), false)
+	os.Unsetenv("NO_COLOR")
+
+	set.Parse([]string{"--no-color"})
+	expect(t, c.useColor(), false)
+}
+
+func TestApp_ColorHelpAddsNoColorFlag(t *testing.T) {
+	os.Unsetenv("NO_COLOR")
+	app := NewApp()
+	app.ColorHelp = true
+	useColor := true
+	app.Action = func(c *Context) {
+		useColor = c.useColor()
+	}
+	expect(t, app.Run([]string{"app", "--no-color"}), nil)
+	expect(t, useColor, false)
+
+	app.ColorHelp = false
+	expect(t, len(app.flags()), 1)
+}
+
+func TestApp_NoColorFlagOverlap(t *testing.T) {
+	os.Unsetenv("NO_COLOR")
+	color := true
+	app := NewApp()
+	app.ColorHelp = true
+	app.Flags = []Flag{BoolFlag{Name: "color", Value: true, Negatable: true}}
+	app.Action = func(c *Context) {
+		color = c.Bool("color")
+	}
+	expect(t, len(app.flags()), 2)
+	expect(t, app.Run([]string{"app", "--no-color"}), nil)
+	expect(t, color, false)
+}
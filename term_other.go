@@ -0,0 +1,29 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package cli
+
+import "errors"
+
+// termState is the terminal configuration saved by makeRaw.
+type termState struct{}
+
+// isTerminal reports whether fd refers to a terminal. Terminals are not
+// detected on this platform.
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+// terminalSize is not supported on this platform.
+func terminalSize(fd uintptr) (width, height int, ok bool) {
+	return 0, 0, false
+}
+
+// makeRaw is not supported on this platform.
+func makeRaw(fd uintptr) (*termState, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+// restoreTerminal is not supported on this platform.
+func restoreTerminal(fd uintptr, state *termState) error {
+	return nil
+}
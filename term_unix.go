@@ -0,0 +1,75 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package cli
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// termState is the terminal configuration saved by makeRaw.
+type termState struct {
+	termios syscall.Termios
+}
+
+func getTermios(fd uintptr) (*syscall.Termios, error) {
+	var termios syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&termios))); errno != 0 {
+		return nil, errno
+	}
+	return &termios, nil
+}
+
+func setTermios(fd uintptr, termios *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isTerminal reports whether fd refers to a terminal.
+func isTerminal(fd uintptr) bool {
+	_, err := getTermios(fd)
+	return err == nil
+}
+
+// winsize is the terminal size reported by TIOCGWINSZ.
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// terminalSize returns the number of columns and rows of the terminal fd.
+func terminalSize(fd uintptr) (width, height int, ok bool) {
+	var ws winsize
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); errno != 0 || ws.cols == 0 {
+		return 0, 0, false
+	}
+	return int(ws.cols), int(ws.rows), true
+}
+
+// makeRaw puts the terminal fd into raw mode, in which input is available
+// byte by byte and is not echoed, and returns the previous state.
+func makeRaw(fd uintptr) (*termState, error) {
+	termios, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	old := &termState{termios: *termios}
+
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	termios.Oflag &^= syscall.OPOST
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB
+	termios.Cflag |= syscall.CS8
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	if err := setTermios(fd, termios); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// restoreTerminal puts the terminal fd back into a state saved by makeRaw.
+func restoreTerminal(fd uintptr, state *termState) error {
+	return setTermios(fd, &state.termios)
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+)
+
+// tokenize splits a command line into arguments following the quoting rules
+// of a POSIX shell: whitespace separates arguments, single quotes preserve
+// everything literally, double quotes preserve everything except a backslash
+// before ", \, $ or `, and a backslash outside quotes escapes the next
+// character. No variables or globs are expanded.
+func tokenize(line string) ([]string, error) {
+	return tokenizeExpand(line, nil)
+}
+
+// tokenizeExpand is like tokenize, but replaces $NAME and ${NAME} outside
+// single quotes with the result of expand. Expanded values are not split into
+// several arguments.
+func tokenizeExpand(line string, expand func(name string) string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case r == '\\':
+			inArg = true
+			if i+1 < len(runes) {
+				i++
+				if runes[i] != '\n' {
+					arg.WriteRune(runes[i])
+				}
+			}
+		case r == '\'':
+			inArg = true
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			arg.WriteString(string(runes[i+1 : end]))
+			i = end
+		case r == '$' && expand != nil:
+			inArg = true
+			value, end := expandVariable(runes, i, expand)
+			arg.WriteString(value)
+			i = end
+		case r == '"':
+			inArg = true
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+				} else if runes[i] == '$' && expand != nil {
+					value, end := expandVariable(runes, i, expand)
+					arg.WriteString(value)
+					i = end
+					continue
+				}
+				arg.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unterminated double quote")
+			}
+		default:
+			inArg = true
+			arg.WriteRune(r)
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// expandVariable expands the variable reference starting with the $ at
+// runes[i] and returns its value and the index of its last rune. A $ that
+// does not start a variable name is returned as is.
+func expandVariable(runes []rune, i int, expand func(string) string) (string, int) {
+	if i+1 < len(runes) && runes[i+1] == '{' {
+		if end := indexRune(runes, i+2, '}'); end >= 0 {
+			return expand(string(runes[i+2 : end])), end
+		}
+		return "$", i
+	}
+
+	end := i + 1
+	for end < len(runes) && isNameRune(runes[end], end == i+1) {
+		end++
+	}
+	if end == i+1 {
+		return "$", i
+	}
+	return expand(string(runes[i+1 : end])), end - 1
+}
+
+func isNameRune(r rune, first bool) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (!first && r >= '0' && r <= '9')
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
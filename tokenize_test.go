@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+var tokenizeTests = []struct {
+	line     string
+	expected []string
+}{
+	{"", nil},
+	{"  status  ", []string{"status"}},
+	{"commit -m 'first commit'", []string{"commit", "-m", "first commit"}},
+	{`say "hello \"world\"" \$HOME`, []string{"say", `hello "world"`, "$HOME"}},
+	{`say "a\nb" 'c\d'`, []string{"say", `a\nb`, `c\d`}},
+	{`x""y '' ""`, []string{"xy", "", ""}},
+	{"one\\ arg two", []string{"one arg", "two"}},
+}
+
+func TestTokenize(t *testing.T) {
+	for _, test := range tokenizeTests {
+		actual, err := tokenize(test.line)
+		expect(t, err, nil)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("tokenize(%q): expected %q, got %q", test.line, test.expected, actual)
+		}
+	}
+}
+
+func TestTokenizeUnterminated(t *testing.T) {
+	_, err := tokenize("say 'hello")
+	expect(t, err.Error(), "unterminated single quote")
+	_, err = tokenize(`say "hello`)
+	expect(t, err.Error(), "unterminated double quote")
+}
+
+func TestTokenizeExpand(t *testing.T) {
+	vars := map[string]string{"NAME": "web server", "N": "3"}
+	expand := func(name string) string {
+		return vars[name]
+	}
+
+	actual, err := tokenizeExpand(`deploy $NAME "${NAME}-$N" '$NAME' \$NAME $ $1 $UNSET x`, expand)
+	expect(t, err, nil)
+	expected := []string{"deploy", "web server", "web server-3", "$NAME", "$NAME", "$", "$1", "", "x"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,504 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The flag.Value implementations in this file back the built-in flag types that
+// the standard flag package does not provide. Errors returned from Set describe
+// the expected format; the flag package prefixes them with the offending value
+// and flag name.
+
+// fileValue wraps the value of a flag with AllowFileValue set. A value of
+// @path is replaced by the contents of the file at path and a value of - by the
+// contents of the Reader of the App, without trailing newlines. A value starting with @@ is
+// passed on literally with the first @ removed.
+type fileValue struct {
+	flag.Value
+	// set while copying a value between forms of the same flag
+	literal bool
+	// where a value of - is read from, the Reader of the App
+	stdin io.Reader
+}
+
+func (f *fileValue) Set(s string) error {
+	if f.literal {
+		return f.Value.Set(s)
+	}
+	switch {
+	case strings.HasPrefix(s, "@@"):
+		return f.Value.Set(s[1:])
+	case strings.HasPrefix(s, "@"):
+		data, err := ioutil.ReadFile(s[1:])
+		if err != nil {
+			return fmt.Errorf("cannot read value from file: %v", err)
+		}
+		return f.Value.Set(trimNewlines(string(data)))
+	case s == "-":
+		data, err := ioutil.ReadAll(f.stdin)
+		if err != nil {
+			return fmt.Errorf("cannot read value from stdin: %v", err)
+		}
+		return f.Value.Set(trimNewlines(string(data)))
+	}
+	return f.Value.Set(s)
+}
+
+func (f *fileValue) Get() interface{} {
+	if g, ok := f.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return f.Value
+}
+
+type boolValue bool
+
+func (b *boolValue) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New("expected true or false")
+	}
+	*b = boolValue(v)
+	return nil
+}
+
+func (b *boolValue) Get() interface{} { return bool(*b) }
+
+func (b *boolValue) String() string {
+	if b == nil {
+		return "false"
+	}
+	return strconv.FormatBool(bool(*b))
+}
+
+func (b *boolValue) IsBoolFlag() bool { return true }
+
+// negatedBoolValue backs the --no-<name> form of a negatable BoolFlag and
+// stores the inverse of what it is given.
+type negatedBoolValue bool
+
+func (b *negatedBoolValue) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New("expected a boolean")
+	}
+	*b = negatedBoolValue(!v)
+	return nil
+}
+
+func (b *negatedBoolValue) Get() interface{} { return !bool(*b) }
+
+func (b *negatedBoolValue) String() string {
+	if b == nil {
+		return "false"
+	}
+	return strconv.FormatBool(!bool(*b))
+}
+
+func (b *negatedBoolValue) IsBoolFlag() bool { return true }
+
+// countValue backs a CountFlag. Each occurrence of the flag increments it; an
+// explicit number replaces the count.
+type countValue int
+
+func (c *countValue) Set(s string) error {
+	// numbers come first, so that copying the count to another form of the
+	// flag does not count it again
+	if v, err := strconv.Atoi(s); err == nil {
+		if v < 0 {
+			return errors.New("expected a non-negative count")
+		}
+		*c = countValue(v)
+		return nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New("expected a non-negative count")
+	}
+	if v {
+		*c++
+	} else {
+		*c = 0
+	}
+	return nil
+}
+
+// parseCount parses an absolute count: a non-negative number, or a boolean
+// for a count of 1 or 0.
+func parseCount(s string) (int, error) {
+	if v, err := strconv.Atoi(s); err == nil && v >= 0 {
+		return v, nil
+	}
+	if v, err := strconv.ParseBool(s); err == nil {
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, errors.New("expected a non-negative count")
+}
+
+func (c *countValue) Get() interface{} { return int(*c) }
+
+func (c *countValue) String() string {
+	if c == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*c))
+}
+
+func (c *countValue) IsBoolFlag() bool { return true }
+
+type intValue int
+
+func (i *intValue) Set(s string) error {
+	v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	if err != nil {
+		return errors.New("expected an integer")
+	}
+	*i = intValue(v)
+	return nil
+}
+
+func (i *intValue) Get() interface{} { return int(*i) }
+
+func (i *intValue) String() string { return strconv.Itoa(int(*i)) }
+
+type float64Value float64
+
+func (f *float64Value) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return errors.New("expected a number")
+	}
+	*f = float64Value(v)
+	return nil
+}
+
+func (f *float64Value) Get() interface{} { return float64(*f) }
+
+func (f *float64Value) String() string { return strconv.FormatFloat(float64(*f), 'g', -1, 64) }
+
+type durationValue time.Duration
+
+func (d *durationValue) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New("expected a duration such as 45s or 1h30m")
+	}
+	*d = durationValue(v)
+	return nil
+}
+
+func (d *durationValue) Get() interface{} { return time.Duration(*d) }
+
+func (d *durationValue) String() string { return time.Duration(*d).String() }
+
+type int64Value int64
+
+func (i *int64Value) Set(s string) error {
+	v, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		return errors.New("expected a 64-bit integer")
+	}
+	*i = int64Value(v)
+	return nil
+}
+
+func (i *int64Value) Get() interface{} { return int64(*i) }
+
+func (i *int64Value) String() string { return strconv.FormatInt(int64(*i), 10) }
+
+type uintValue uint
+
+func (i *uintValue) Set(s string) error {
+	v, err := strconv.ParseUint(s, 0, strconv.IntSize)
+	if err != nil {
+		return errors.New("expected a non-negative integer")
+	}
+	*i = uintValue(v)
+	return nil
+}
+
+func (i *uintValue) Get() interface{} { return uint(*i) }
+
+func (i *uintValue) String() string { return strconv.FormatUint(uint64(*i), 10) }
+
+type uint64Value uint64
+
+func (i *uint64Value) Set(s string) error {
+	v, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return errors.New("expected a non-negative 64-bit integer")
+	}
+	*i = uint64Value(v)
+	return nil
+}
+
+func (i *uint64Value) Get() interface{} { return uint64(*i) }
+
+func (i *uint64Value) String() string { return strconv.FormatUint(uint64(*i), 10) }
+
+type timestampValue struct {
+	time   time.Time
+	layout string
+}
+
+func (t *timestampValue) Set(s string) error {
+	v, err := time.Parse(t.layout, s)
+	if err != nil {
+		return fmt.Errorf("expected a timestamp in the format %q", t.layout)
+	}
+	t.time = v
+	return nil
+}
+
+func (t *timestampValue) Get() interface{} { return t.time }
+
+func (t *timestampValue) String() string {
+	if t.time.IsZero() {
+		return ""
+	}
+	return t.time.Format(t.layout)
+}
+
+// ByteSize is a number of bytes. It can be parsed from, and is printed as, a
+// human readable size such as "512MiB".
+type ByteSize uint64
+
+// Units understood by ParseByteSize. Decimal units are powers of 1000 and binary
+// units are powers of 1024.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+)
+
+// byteSizeUnits lists the binary units first so that String can prefer them.
+var byteSizeUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB},
+	{"PB", PB}, {"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB},
+	{"B", Byte},
+}
+
+// ParseByteSize parses a size such as "512MiB", "1.5GB" or "4096". Unit
+// suffixes are case-insensitive and a number without a suffix is in bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	num, unit := str, Byte
+	for _, u := range byteSizeUnits {
+		if len(str) > len(u.suffix) && strings.EqualFold(str[len(str)-len(u.suffix):], u.suffix) {
+			num, unit = strings.TrimSpace(str[:len(str)-len(u.suffix)]), u.size
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	size := v * float64(unit)
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("byte size %q is too large", s)
+	}
+	return ByteSize(size), nil
+}
+
+// String returns the size using the largest binary unit that represents it
+// exactly, so that the result can be parsed back by ParseByteSize.
+func (b ByteSize) String() string {
+	for _, u := range byteSizeUnits[:5] {
+		if b >= u.size && b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.suffix
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+func (b *ByteSize) Set(s string) error {
+	v, err := ParseByteSize(s)
+	if err != nil {
+		return errors.New("expected a size such as 1024, 10KB or 512MiB")
+	}
+	*b = v
+	return nil
+}
+
+func (b *ByteSize) Get() interface{} { return *b }
+
+type urlValue struct {
+	url *url.URL
+}
+
+func (u *urlValue) Set(s string) error {
+	v, err := url.Parse(s)
+	if err != nil || !v.IsAbs() {
+		return errors.New("expected an absolute URL such as https://example.com")
+	}
+	u.url = v
+	return nil
+}
+
+func (u *urlValue) Get() interface{} { return u.url }
+
+func (u *urlValue) String() string {
+	if u.url == nil {
+		return ""
+	}
+	return u.url.String()
+}
+
+func copyURL(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+	c := *u
+	return &c
+}
+
+type ipValue struct {
+	ip net.IP
+}
+
+func (i *ipValue) Set(s string) error {
+	v := net.ParseIP(s)
+	if v == nil {
+		return errors.New("expected an IPv4 or IPv6 address")
+	}
+	i.ip = v
+	return nil
+}
+
+func (i *ipValue) Get() interface{} { return i.ip }
+
+func (i *ipValue) String() string {
+	if i.ip == nil {
+		return ""
+	}
+	return i.ip.String()
+}
+
+type cidrValue struct {
+	network *net.IPNet
+}
+
+func (c *cidrValue) Set(s string) error {
+	_, v, err := net.ParseCIDR(s)
+	if err != nil {
+		return errors.New("expected a network in CIDR notation such as 10.0.0.0/8")
+	}
+	c.network = v
+	return nil
+}
+
+func (c *cidrValue) Get() interface{} { return c.network }
+
+func (c *cidrValue) String() string {
+	if c.network == nil {
+		return ""
+	}
+	return c.network.String()
+}
+
+type regexpValue struct {
+	re *regexp.Regexp
+}
+
+func (r *regexpValue) Set(s string) error {
+	v, err := regexp.Compile(s)
+	if err != nil {
+		return fmt.Errorf("expected a regular expression: %v", strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+	}
+	r.re = v
+	return nil
+}
+
+func (r *regexpValue) Get() interface{} { return r.re }
+
+func (r *regexpValue) String() string {
+	if r.re == nil {
+		return ""
+	}
+	return r.re.String()
+}
+
+type choiceValue struct {
+	value      string
+	allowed    []string
+	ignoreCase bool
+}
+
+func (c *choiceValue) Set(s string) error {
+	v, err := c.match(s)
+	if err != nil {
+		return err
+	}
+	c.value = v
+	return nil
+}
+
+func (c *choiceValue) Get() interface{} { return c.value }
+
+func (c *choiceValue) String() string { return c.value }
+
+func (c *choiceValue) match(s string) (string, error) {
+	for _, a := range c.allowed {
+		if s == a || (c.ignoreCase && strings.EqualFold(s, a)) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("expected one of %s", strings.Join(c.allowed, ", "))
+}
+
+type choiceSliceValue struct {
+	choice  choiceValue
+	values  []string
+	changed bool
+}
+
+// Set validates a comma-separated list of values. The first call replaces the
+// default values, later calls append to them.
+func (c *choiceSliceValue) Set(s string) error {
+	var values []string
+	for _, part := range strings.Split(s, ",") {
+		v, err := c.choice.match(strings.TrimSpace(part))
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+	}
+	if !c.changed {
+		c.values = nil
+		c.changed = true
+	}
+	c.values = append(c.values, values...)
+	return nil
+}
+
+func (c *choiceSliceValue) Get() interface{} { return c.values }
+
+func (c *choiceSliceValue) String() string { return strings.Join(c.values, ",") }
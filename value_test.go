@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+)
+
+var parseByteSizeTests = []struct {
+	input    string
+	expected ByteSize
+}{
+	{"0", 0},
+	{"4096", 4096},
+	{"10B", 10},
+	{"10KB", 10000},
+	{"10kb", 10000},
+	{"512MiB", 512 * MiB},
+	{"1.5GB", 1500 * MB},
+	{"2 TiB", 2 * TiB},
+}
+
+func TestParseByteSize(t *testing.T) {
+	for _, test := range parseByteSizeTests {
+		size, err := ParseByteSize(test.input)
+		expect(t, err, nil)
+		expect(t, size, test.expected)
+	}
+
+	for _, input := range []string{"", "MiB", "-1KB", "ten"} {
+		if _, err := ParseByteSize(input); err == nil {
+			t.Errorf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	expect(t, ByteSize(0).String(), "0B")
+	expect(t, ByteSize(1000).String(), "1000B")
+	expect(t, (512 * MiB).String(), "512MiB")
+	expect(t, (1536 * KiB).String(), "1536KiB")
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// yamlMap is a JSON object with its keys in their original order.
+type yamlMap []yamlEntry
+
+type yamlEntry struct {
+	key   string
+	value interface{}
+}
+
+// yamlPlain matches the strings that need no quotes in YAML, apart from the
+// words in yamlReserved.
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./@()-]*( [A-Za-z0-9_./@()-]+)*$`)
+
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "y": true, "n": true,
+	"on": true, "off": true, "null": true,
+}
+
+// marshalYAML encodes v as YAML. It is encoded as JSON first, so json struct
+// tags and MarshalJSON methods apply, and the fields keep their order.
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.Join(yamlLines(node), "\n") + "\n"), nil
+}
+
+// decodeOrdered decodes the next JSON value of dec, keeping objects as yamlMap.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := yamlMap{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, yamlEntry{key.(string), value})
+		}
+		_, err = dec.Token()
+		return m, err
+	case json.Delim('['):
+		l := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, value)
+		}
+		_, err = dec.Token()
+		return l, err
+	}
+	return tok, nil
+}
+
+// yamlLines returns the lines of node in block style, unindented.
+func yamlLines(node interface{}) []string {
+	var lines []string
+	switch n := node.(type) {
+	case yamlMap:
+		if len(n) == 0 {
+			return []string{"{}"}
+		}
+		for _, e := range n {
+			key := yamlScalar(e.key)
+			if isYAMLBlock(e.value) {
+				lines = append(lines, key+":")
+				for _, line := range yamlLines(e.value) {
+					lines = append(lines, "  "+line)
+				}
+			} else {
+				lines = append(lines, key+": "+yamlLines(e.value)[0])
+			}
+		}
+	case []interface{}:
+		if len(n) == 0 {
+			return []string{"[]"}
+		}
+		for _, item := range n {
+			for i, line := range yamlLines(item) {
+				if i == 0 {
+					lines = append(lines, "- "+line)
+				} else {
+					lines = append(lines, "  "+line)
+				}
+			}
+		}
+	default:
+		lines = []string{yamlScalar(node)}
+	}
+	return lines
+}
+
+// isYAMLBlock reports whether node is written on lines of its own.
+func isYAMLBlock(node interface{}) bool {
+	switch n := node.(type) {
+	case yamlMap:
+		return len(n) > 0
+	case []interface{}:
+		return len(n) > 0
+	}
+	return false
+}
+
+func yamlScalar(node interface{}) string {
+	switch n := node.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(n)
+	case json.Number:
+		return n.String()
+	case string:
+		if yamlPlain.MatchString(n) && !yamlReserved[strings.ToLower(n)] {
+			return n
+		}
+		return strconv.Quote(n)
+	}
+	return ""
+}